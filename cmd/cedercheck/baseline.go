@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// A baseline records the findings accepted when adopting the analyzers on
+// existing code. Findings are keyed by analyzer, file, enclosing function and
+// message rather than by line, so that unrelated edits don't invalidate the
+// baseline. The baseline is a JSON file:
+//
+//	{
+//		"findings": [
+//			{
+//				"analyzer": "nakedreturn",
+//				"file": "client/client.go",
+//				"function": "Client.Do",
+//				"message": "return values not explicitly specified",
+//				"count": 2
+//			}
+//		]
+//	}
+//
+// Files are relative to the directory of the baseline.
+type baseline struct {
+	Findings []*baselineEntry `json:"findings"`
+}
+
+type baselineEntry struct {
+	baselineKey
+	// Count is the number of findings with the key.
+	Count int `json:"count"`
+}
+
+type baselineKey struct {
+	Analyzer string `json:"analyzer"`
+	File     string `json:"file"`
+	Function string `json:"function,omitempty"`
+	Message  string `json:"message"`
+}
+
+// applyBaseline returns the findings that aren't recorded in the baseline
+// file at path. If the file doesn't exist, it is written with all findings.
+// If update is set, the fixed findings are removed from the file, but new
+// ones aren't added. Only the findings of files in analyzed can be fixed, so
+// that updating after analyzing some packages keeps the others.
+func applyBaseline(path string, findings []*finding, analyzed analyzedFiles, update bool) ([]*finding, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	relFile := func(file string) string {
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			file = rel
+		}
+		return filepath.ToSlash(file)
+	}
+	keyOf := func(f *finding) baselineKey {
+		return baselineKey{
+			Analyzer: f.analyzer.Name,
+			File:     relFile(f.posn.Filename),
+			Function: f.function,
+			Message:  f.diagnostic.Message,
+		}
+	}
+	current := map[baselineKey]int{}
+	for _, f := range findings {
+		current[keyOf(f)]++
+	}
+
+	recorded, err := readBaseline(path)
+	if os.IsNotExist(err) {
+		return nil, writeBaseline(path, current)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	budget := map[baselineKey]int{}
+	for k, n := range recorded {
+		budget[k] = n
+	}
+	var remaining []*finding
+	for _, f := range findings {
+		k := keyOf(f)
+		if budget[k] > 0 {
+			budget[k]--
+			continue
+		}
+		remaining = append(remaining, f)
+	}
+
+	if update {
+		checked := map[string]map[string]bool{}
+		for name, files := range analyzed {
+			checked[name] = map[string]bool{}
+			for file := range files {
+				checked[name][relFile(file)] = true
+			}
+		}
+		shrunk := map[baselineKey]int{}
+		for k, n := range recorded {
+			if checked[k.Analyzer][k.File] && current[k] < n {
+				n = current[k]
+			}
+			if n > 0 {
+				shrunk[k] = n
+			}
+		}
+		if err := writeBaseline(path, shrunk); err != nil {
+			return nil, err
+		}
+	}
+	return remaining, nil
+}
+
+// readBaseline reads the baseline file at path.
+func readBaseline(path string) (map[baselineKey]int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	counts := map[baselineKey]int{}
+	for _, e := range b.Findings {
+		counts[e.baselineKey] += e.Count
+	}
+	return counts, nil
+}
+
+// writeBaseline writes a baseline file recording counts to path.
+func writeBaseline(path string, counts map[baselineKey]int) error {
+	b := baseline{Findings: []*baselineEntry{}}
+	for k, n := range counts {
+		b.Findings = append(b.Findings, &baselineEntry{baselineKey: k, Count: n})
+	}
+	sort.Slice(b.Findings, func(ii, jj int) bool {
+		ki, kj := b.Findings[ii].baselineKey, b.Findings[jj].baselineKey
+		if ki.File != kj.File {
+			return ki.File < kj.File
+		}
+		if ki.Function != kj.Function {
+			return ki.Function < kj.Function
+		}
+		if ki.Analyzer != kj.Analyzer {
+			return ki.Analyzer < kj.Analyzer
+		}
+		return ki.Message < kj.Message
+	})
+	data, err := json.MarshalIndent(b, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+	a := &analysis.Analyzer{Name: "a"}
+	newFinding := func(line int, function, message string) *finding {
+		return &finding{
+			analyzer:   a,
+			posn:       token.Position{Filename: filepath.Join(dir, "pkg", "a.go"), Line: line},
+			function:   function,
+			diagnostic: analysis.Diagnostic{Message: message},
+		}
+	}
+
+	// The baseline is written with all findings if it doesn't exist.
+	findings := []*finding{
+		newFinding(1, "F", "m"),
+		newFinding(2, "F", "m"),
+		newFinding(3, "G", "m"),
+	}
+	remaining, err := applyBaseline(path, findings, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("applyBaseline() of a new baseline = %v, want none", remaining)
+	}
+	want := map[baselineKey]int{
+		{Analyzer: "a", File: "pkg/a.go", Function: "F", Message: "m"}: 2,
+		{Analyzer: "a", File: "pkg/a.go", Function: "G", Message: "m"}: 1,
+	}
+	if got, err := readBaseline(path); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("baseline = %v, %v, want %v", got, err, want)
+	}
+
+	// Moved findings are still recorded, while new findings are not.
+	findings = []*finding{
+		newFinding(10, "F", "m"),
+		newFinding(11, "F", "m"),
+		newFinding(12, "F", "m"),
+		newFinding(13, "H", "m"),
+	}
+	remaining, err = applyBaseline(path, findings, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := findings[2:]; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("applyBaseline() = %v, want %v", remaining, want)
+	}
+	if got, err := readBaseline(path); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("baseline = %v, %v, want it unchanged", got, err)
+	}
+
+	// Updating removes the fixed findings without adding new ones.
+	findings = []*finding{
+		newFinding(10, "F", "m"),
+		newFinding(13, "H", "m"),
+	}
+	analyzed := analyzedFiles{"a": {filepath.Join(dir, "pkg", "a.go"): true}}
+	remaining, err = applyBaseline(path, findings, analyzed, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := findings[1:]; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("applyBaseline() = %v, want %v", remaining, want)
+	}
+	want = map[baselineKey]int{
+		{Analyzer: "a", File: "pkg/a.go", Function: "F", Message: "m"}: 1,
+	}
+	if got, err := readBaseline(path); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("updated baseline = %v, %v, want %v", got, err, want)
+	}
+}
+
+// TestUpdateBaselinePartially checks that updating a baseline after analyzing
+// some of the packages, or with analyses that failed, keeps the findings of
+// the files that weren't analyzed.
+func TestUpdateBaselinePartially(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+	a, b := &analysis.Analyzer{Name: "a"}, &analysis.Analyzer{Name: "b"}
+	newFinding := func(analyzer *analysis.Analyzer, file string) *finding {
+		return &finding{
+			analyzer:   analyzer,
+			posn:       token.Position{Filename: filepath.Join(dir, file, file+".go")},
+			diagnostic: analysis.Diagnostic{Message: "m"},
+		}
+	}
+	findings := []*finding{
+		newFinding(a, "a"),
+		newFinding(a, "b"),
+		newFinding(b, "a"),
+	}
+	if _, err := applyBaseline(path, findings, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only a/a.go was analyzed, by a alone, and its finding was fixed.
+	analyzed := analyzedFiles{"a": {filepath.Join(dir, "a", "a.go"): true}}
+	remaining, err := applyBaseline(path, nil, analyzed, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("applyBaseline() = %v, want none", remaining)
+	}
+	want := map[baselineKey]int{
+		{Analyzer: "a", File: "b/b.go", Message: "m"}: 1,
+		{Analyzer: "b", File: "a/a.go", Message: "m"}: 1,
+	}
+	if got, err := readBaseline(path); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("updated baseline = %v, %v, want %v", got, err, want)
+	}
+}
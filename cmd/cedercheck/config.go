@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cederstone/analysis/internal/pattern"
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the name of the config file read from the root of the module
+// containing the working directory.
+const configFile = ".cedercheck.yaml"
+
+// config is the contents of the config file. It enables and disables
+// analyzers and sets their flags, for all packages and for the packages
+// matching patterns:
+//
+//	analyzers:
+//	  nakedreturn:
+//	    enabled: false
+//	  keyedlit:
+//	    flags:
+//	      strict: true
+//	packages:
+//	  # Later entries take precedence over earlier ones.
+//	  - pattern: github.com/ourorg/legacy/...
+//	    analyzers:
+//	      keyedlit:
+//	        flags:
+//	          strict: false
+//
+// Analyzers are enabled unless disabled. Patterns match package paths, '...'
+// matching any string as in the go command. Flags set on the command line
+// take precedence over those set for all packages, but not over those set
+// for some packages.
+type config struct {
+	Analyzers map[string]*analyzerConfig `yaml:"analyzers"`
+	Packages  []*packageConfig           `yaml:"packages"`
+}
+
+// An analyzerConfig configures an analyzer.
+type analyzerConfig struct {
+	Enabled *bool                  `yaml:"enabled"`
+	Flags   map[string]interface{} `yaml:"flags"`
+}
+
+// A packageConfig configures analyzers for the packages matching a pattern.
+type packageConfig struct {
+	Pattern   string                     `yaml:"pattern"`
+	Analyzers map[string]*analyzerConfig `yaml:"analyzers"`
+}
+
+// findConfig returns the path of the config file at the root of the module
+// containing dir, or an empty path if there is none.
+func findConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			path := filepath.Join(dir, configFile)
+			if _, err := os.Stat(path); err != nil {
+				return "", nil
+			}
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads the config file at path.
+func loadConfig(path string) (*config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(config)
+	// Misspelled keys are errors rather than silently ignored.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// apply returns the analyzers enabled by cfg, with the flags it sets for all
+// packages set. Analyzers configured for some packages are replaced by copies
+// that apply the configuration when run.
+func (cfg *config) apply(analyzers []*analysis.Analyzer) ([]*analysis.Analyzer, error) {
+	byName := map[string]*analysis.Analyzer{}
+	for _, a := range analyzers {
+		byName[a.Name] = a
+	}
+	if err := validate(byName, cfg.Analyzers); err != nil {
+		return nil, err
+	}
+	for ii, pc := range cfg.Packages {
+		if pc.Pattern == "" {
+			return nil, fmt.Errorf("packages entry %d has no pattern", ii+1)
+		}
+		if err := validate(byName, pc.Analyzers); err != nil {
+			return nil, fmt.Errorf("packages entry %d: %v", ii+1, err)
+		}
+	}
+
+	var enabled []*analysis.Analyzer
+	for _, a := range analyzers {
+		global := cfg.Analyzers[a.Name]
+		for _, name := range sortedKeys(global.flags()) {
+			if err := a.Flags.Set(name, global.flags()[name]); err != nil {
+				return nil, fmt.Errorf("%s: flag %s: %v", a.Name, name, err)
+			}
+		}
+		scoped := false
+		for _, pc := range cfg.Packages {
+			if pc.Analyzers[a.Name] != nil {
+				scoped = true
+			}
+		}
+		switch {
+		case scoped:
+			enabled = append(enabled, cfg.scope(a))
+		case global.isEnabled():
+			enabled = append(enabled, a)
+		}
+	}
+	return enabled, nil
+}
+
+// validate checks that analyzers configures known analyzers and flags.
+func validate(byName map[string]*analysis.Analyzer, analyzers map[string]*analyzerConfig) error {
+	for name, ac := range analyzers {
+		a, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown analyzer %s", name)
+		}
+		for flag := range ac.flags() {
+			if a.Flags.Lookup(flag) == nil {
+				return fmt.Errorf("%s: unknown flag %s", name, flag)
+			}
+		}
+	}
+	return nil
+}
+
+// scope returns a copy of a that applies the configuration for the package
+// it runs on. Diagnostics are dropped in packages for which a is disabled,
+// but it still runs so that it exports facts for dependent packages.
+//
+// Analyzers keep their flags in package variables, so runs of the copy are
+// serialized if flags are set for some packages.
+func (cfg *config) scope(a *analysis.Analyzer) *analysis.Analyzer {
+	var mu sync.Mutex
+	serialize := false
+	for _, pc := range cfg.Packages {
+		if len(pc.Analyzers[a.Name].flags()) != 0 {
+			serialize = true
+		}
+	}
+	scoped := *a
+	scoped.Run = func(pass *analysis.Pass) (interface{}, error) {
+		enabled := cfg.Analyzers[a.Name].isEnabled()
+		flags := map[string]string{}
+		for _, pc := range cfg.Packages {
+			ac := pc.Analyzers[a.Name]
+			if ac == nil || !pattern.Match(pc.Pattern, pass.Pkg.Path()) {
+				continue
+			}
+			if ac.Enabled != nil {
+				enabled = *ac.Enabled
+			}
+			for name, value := range ac.flags() {
+				flags[name] = value
+			}
+		}
+		if !enabled {
+			p := *pass
+			p.Report = func(analysis.Diagnostic) {}
+			pass = &p
+		}
+		if !serialize {
+			return a.Run(pass)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for name, value := range flags {
+			f := a.Flags.Lookup(name)
+			defer f.Value.Set(f.Value.String())
+			if err := f.Value.Set(value); err != nil {
+				return nil, fmt.Errorf("flag %s: %v", name, err)
+			}
+		}
+		return a.Run(pass)
+	}
+	return &scoped
+}
+
+func (ac *analyzerConfig) isEnabled() bool {
+	return ac == nil || ac.Enabled == nil || *ac.Enabled
+}
+
+// flags returns the flags set by ac as strings, as YAML scalars like 'true'
+// aren't decoded as strings.
+func (ac *analyzerConfig) flags() map[string]string {
+	flags := map[string]string{}
+	if ac == nil {
+		return flags
+	}
+	for name, value := range ac.Flags {
+		switch value := value.(type) {
+		case []interface{}:
+			// Lists are passed as comma-separated values.
+			var values []string
+			for _, v := range value {
+				values = append(values, fmt.Sprint(v))
+			}
+			flags[name] = strings.Join(values, ",")
+		default:
+			flags[name] = fmt.Sprint(value)
+		}
+	}
+	return flags
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// newModeAnalyzer returns an analyzer reporting the value of its 'mode' flag
+// at the package clause of each file.
+func newModeAnalyzer() *analysis.Analyzer {
+	a := &analysis.Analyzer{Name: "mode", Doc: "report the mode flag"}
+	mode := a.Flags.String("mode", "default", "mode to report")
+	a.Run = func(pass *analysis.Pass) (interface{}, error) {
+		for _, file := range pass.Files {
+			pass.Reportf(file.Package, "mode %s", *mode)
+		}
+		return nil, nil
+	}
+	return a
+}
+
+func TestConfig(t *testing.T) {
+	testdata := analysistest.TestData()
+	cfg, err := loadConfig(filepath.Join(testdata, "cedercheck.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode := newModeAnalyzer()
+	disabled := &analysis.Analyzer{Name: "disabled", Doc: "disabled", Run: mode.Run}
+	analyzers, err := cfg.apply([]*analysis.Analyzer{mode, disabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzers) != 1 || analyzers[0].Name != "mode" {
+		t.Fatalf("apply() enabled %v, want only the mode analyzer", analyzers)
+	}
+	analysistest.Run(t, testdata, analyzers[0], "app", "legacy", "generated")
+	if got := mode.Flags.Lookup("mode").Value.String(); got != "global" {
+		t.Errorf("mode flag is %q after the runs, want %q", got, "global")
+	}
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	for _, src := range []string{
+		"analyzer:\n  mode:\n    enabled: false\n",
+		"analyzers:\n  mode:\n    flag:\n      mode: local\n",
+		"packages:\n  - pattern: app\n    analyzer: {}\n",
+	} {
+		path := filepath.Join(t.TempDir(), configFile)
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfig(path); err == nil {
+			t.Errorf("loadConfig(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  *config
+	}{{
+		name: "unknown analyzer",
+		cfg:  &config{Analyzers: map[string]*analyzerConfig{"unknown": {}}},
+	}, {
+		name: "unknown flag",
+		cfg: &config{Analyzers: map[string]*analyzerConfig{
+			"mode": {Flags: map[string]interface{}{"unknown": true}},
+		}},
+	}, {
+		name: "missing pattern",
+		cfg: &config{Packages: []*packageConfig{{
+			Analyzers: map[string]*analyzerConfig{"mode": {}},
+		}}},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cfg.apply([]*analysis.Analyzer{newModeAnalyzer()}); err == nil {
+				t.Error("apply() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// changedLines maps the absolute paths of the files changed by a diff to the
+// lines added or changed in each.
+type changedLines map[string]map[int]bool
+
+// readDiff returns the lines changed by the unified diff in the file at path,
+// or in standard input if path is '-'. Paths in the diff are relative to the
+// root of the git repository containing the working directory, as written by
+// 'git diff', or to the working directory outside of git repositories.
+func readDiff(path string) (changedLines, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, err := gitRoot()
+	if err != nil {
+		if root, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	changed, err := parseDiff(bytes.NewReader(data), root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return changed, nil
+}
+
+// diffSince returns the lines of the working tree changed since the git
+// revision rev. Untracked files that aren't ignored are new, and all their
+// lines changed.
+func diffSince(rev string) (changedLines, error) {
+	root, err := gitRoot()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", "diff", "-U0", "--no-color", "--no-ext-diff", rev, "--")
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %v: %s", rev, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	changed, err := parseDiff(bytes.NewReader(out), root)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd = exec.Command("git", "ls-files", "--others", "--exclude-standard", "-z")
+	cmd.Dir = root
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	if out, err = cmd.Output(); err != nil {
+		return nil, fmt.Errorf("git ls-files: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(name))
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		n := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			n++
+		}
+		lines := map[int]bool{}
+		for line := 1; line <= n; line++ {
+			lines[line] = true
+		}
+		changed[path] = lines
+	}
+	return changed, nil
+}
+
+// gitRoot returns the root of the git repository containing the working
+// directory.
+func gitRoot() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// parseDiff returns the lines added or changed by the unified diff r, whose
+// paths are relative to root. Lines only deleted by the diff leave no line
+// to report findings on, and are ignored.
+func parseDiff(r io.Reader, root string) (changedLines, error) {
+	changed := changedLines{}
+	var lines map[int]bool
+	// line is the next line of the new file in the current hunk, and
+	// remaining the number of its lines still to be read.
+	line, remaining := 0, 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		switch {
+		case remaining > 0 && strings.HasPrefix(text, "+"):
+			if lines != nil {
+				lines[line] = true
+			}
+			line++
+			remaining--
+		case remaining > 0 && (strings.HasPrefix(text, " ") || text == ""):
+			line++
+			remaining--
+		case remaining > 0 && strings.HasPrefix(text, "-"),
+			strings.HasPrefix(text, `\`):
+			// A deleted line, or '\ No newline at end of file'.
+		case strings.HasPrefix(text, "+++ "):
+			name, err := diffPath(strings.TrimPrefix(text, "+++ "))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			lines = nil
+			if name != "/dev/null" {
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if changed[path] == nil {
+					changed[path] = map[int]bool{}
+				}
+				lines = changed[path]
+			}
+		case strings.HasPrefix(text, "@@ "):
+			var err error
+			line, remaining, err = parseHunk(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+		default:
+			// A header line, e.g. 'diff --git' or '--- a/file'.
+			remaining = 0
+		}
+	}
+	return changed, scanner.Err()
+}
+
+// diffPath returns the path named by the '+++' header of a file, without the
+// 'b/' prefix written by git and the timestamp written by diff.
+func diffPath(header string) (string, error) {
+	if strings.HasPrefix(header, `"`) {
+		// git quotes paths with unusual characters.
+		end := strings.LastIndex(header, `"`)
+		name, err := strconv.Unquote(header[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("malformed path %s", header)
+		}
+		header = name
+	} else if tab := strings.Index(header, "\t"); tab >= 0 {
+		header = header[:tab]
+	}
+	return strings.TrimPrefix(header, "b/"), nil
+}
+
+// parseHunk returns the first line and the number of lines of the new file
+// in the hunk with the header text, e.g. '@@ -1,2 +3,4 @@'.
+func parseHunk(text string) (start, count int, err error) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, fmt.Errorf("malformed hunk header %s", text)
+	}
+	added := strings.TrimPrefix(fields[2], "+")
+	count = 1
+	if comma := strings.Index(added, ","); comma >= 0 {
+		if count, err = strconv.Atoi(added[comma+1:]); err != nil {
+			return 0, 0, fmt.Errorf("malformed hunk header %s", text)
+		}
+		added = added[:comma]
+	}
+	if start, err = strconv.Atoi(added); err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk header %s", text)
+	}
+	return start, count, nil
+}
+
+// filter returns the findings spanning lines changed by the diff.
+func (changed changedLines) filter(findings []*finding) []*finding {
+	var touched []*finding
+	for _, f := range findings {
+		lines := changed[filepath.Clean(f.posn.Filename)]
+		if lines == nil {
+			continue
+		}
+		last := f.posn.Line
+		if f.diagnostic.End.IsValid() {
+			last = f.fset.Position(f.diagnostic.End).Line
+		}
+		for line := f.posn.Line; line <= last; line++ {
+			if lines[line] {
+				touched = append(touched, f)
+				break
+			}
+		}
+	}
+	return touched
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const testDiff = `diff --git a/pkg/a.go b/pkg/a.go
+index 1111111..2222222 100644
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -3,2 +3,3 @@ func F() {
+ 	x := 1
+-	y := 2
++	y := 3
++	z := 4
+@@ -10 +11 @@ func G() {
+-	return
++	return nil
+diff --git a/pkg/gone.go b/pkg/gone.go
+deleted file mode 100644
+--- a/pkg/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package pkg
+-
+diff --git "a/pkg/b c.go" "b/pkg/b c.go"
+new file mode 100644
+--- /dev/null
++++ "b/pkg/b c.go"
+@@ -0,0 +1,2 @@
++package pkg
++
+\ No newline at end of file
+`
+
+func TestParseDiff(t *testing.T) {
+	root := filepath.FromSlash("/src/module")
+	changed, err := parseDiff(strings.NewReader(testDiff), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := changedLines{
+		filepath.Join(root, "pkg", "a.go"):   {4: true, 5: true, 11: true},
+		filepath.Join(root, "pkg", "b c.go"): {1: true, 2: true},
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("parseDiff() = %v, want %v", changed, want)
+	}
+}
+
+func TestParseDiffErrors(t *testing.T) {
+	for _, diff := range []string{
+		"+++ b/a.go\n@@ -1 +x @@\n",
+		"+++ b/a.go\n@@ -1 +1,x @@\n",
+		"+++ b/a.go\n@@ -1\n",
+		"+++ \"b/a.go\n",
+	} {
+		if _, err := parseDiff(strings.NewReader(diff), "/"); err == nil {
+			t.Errorf("parseDiff(%q) succeeded, want an error", diff)
+		}
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	fset := token.NewFileSet()
+	filename := filepath.FromSlash("/src/module/a.go")
+	tf := fset.AddFile(filename, -1, 100)
+	tf.SetLines([]int{0, 10, 20, 30, 40, 50})
+	newFinding := func(file string, line, endLine int) *finding {
+		d := analysis.Diagnostic{Pos: tf.LineStart(line)}
+		if endLine != 0 {
+			d.End = tf.LineStart(endLine)
+		}
+		return &finding{
+			fset:       fset,
+			posn:       token.Position{Filename: file, Line: line},
+			diagnostic: d,
+		}
+	}
+	findings := []*finding{
+		newFinding(filename, 1, 0),
+		newFinding(filename, 2, 0),
+		newFinding(filename, 3, 5),
+		newFinding(filepath.FromSlash("/src/module/b.go"), 2, 0),
+	}
+	changed := changedLines{filename: {2: true, 4: true}}
+	if got, want := changed.filter(findings), findings[1:3]; !reflect.DeepEqual(got, want) {
+		t.Errorf("filter() = %v, want %v", got, want)
+	}
+}
+
+// TestDiffSince checks that the lines changed since a revision include those
+// of untracked files, but not of ignored ones.
+func TestDiffSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, src string) {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("a.go", "package a\n\nvar x = 1\n")
+	write(".gitignore", "ignored.go\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	write("a.go", "package a\n\nvar x = 2\n")
+	write("new.go", "package a\n\nvar y = 1")
+	write("ignored.go", "package a\n")
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join("sub", "new.go"), "package sub\n")
+
+	changed, err := diffSince("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := changedLines{
+		filepath.Join(root, "a.go"):          {3: true},
+		filepath.Join(root, "new.go"):        {1: true, 2: true, 3: true},
+		filepath.Join(root, "sub", "new.go"): {1: true},
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("diffSince() = %v, want %v", changed, want)
+	}
+}
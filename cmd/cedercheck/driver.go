@@ -0,0 +1,407 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// driverOptions are the values of the flags of the driver of this command,
+// which runs instead of multichecker when one of the flags only the driver
+// defines is set.
+type driverOptions struct {
+	baseline       string
+	updateBaseline bool
+	diff           string
+	since          string
+	format         string
+	lsp            bool
+
+	// The flags below are shared with multichecker.
+	tests bool
+	fix   bool
+	json  bool
+	// enabled are the flags enabling each analyzer.
+	enabled map[*analysis.Analyzer]*enableFlag
+}
+
+// driverFlags are the flags that only the driver defines.
+var driverFlags = []string{"baseline", "update-baseline", "format", "diff-file", "since", "lsp"}
+
+// unsupportedFlags are the flags of multichecker that the driver doesn't
+// support, with whether they are boolean flags.
+var unsupportedFlags = map[string]bool{
+	"c":          false,
+	"diff":       true,
+	"flags":      true,
+	"V":          true,
+	"debug":      false,
+	"cpuprofile": false,
+	"memprofile": false,
+	"trace":      false,
+	"source":     true,
+	"v":          true,
+	"all":        true,
+	"tags":       false,
+}
+
+// addDriverFlags adds the flags that only the driver defines to fs.
+func addDriverFlags(fs *flag.FlagSet, o *driverOptions) {
+	fs.StringVar(&o.baseline, "baseline", "", "report only the findings missing from the baseline `file`, which is written if it doesn't exist")
+	fs.BoolVar(&o.updateBaseline, "update-baseline", false, "remove the fixed findings from the baseline file")
+	fs.StringVar(&o.diff, "diff-file", "", "report only the findings on lines changed by the unified diff in `file`, or in standard input if '-'")
+	fs.StringVar(&o.since, "since", "", "report only the findings on lines changed since the git `revision`, and in untracked files")
+	fs.StringVar(&o.format, "format", "text", "output `format` of the findings, 'text' or 'sarif'")
+	fs.BoolVar(&o.lsp, "lsp", false, "run as a language server over standard input and output, publishing findings of saved documents as diagnostics")
+}
+
+// parseDriverFlags parses args with the flags of the driver and those of
+// multichecker, including the flags of analyzers. It reports whether the
+// driver should run, which it should if one of the flags only the driver
+// defines is set. Otherwise, or if parsing fails, args are left to
+// multichecker, which reports errors.
+func parseDriverFlags(analyzers []*analysis.Analyzer, args []string) (*driverOptions, []string, bool, error) {
+	o := &driverOptions{enabled: map[*analysis.Analyzer]*enableFlag{}}
+	fs := flag.NewFlagSet("cedercheck", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	addDriverFlags(fs, o)
+	fs.BoolVar(&o.tests, "test", true, "indicates whether test files should be analyzed, too")
+	fs.BoolVar(&o.fix, "fix", false, "apply all suggested fixes")
+	fs.BoolVar(&o.json, "json", false, "emit JSON output")
+	for name, isBool := range unsupportedFlags {
+		if isBool {
+			fs.Bool(name, false, "")
+		} else {
+			fs.String(name, "", "")
+		}
+	}
+	for _, a := range analyzers {
+		enable := new(enableFlag)
+		fs.Var(enable, a.Name, fmt.Sprintf("enable %q analysis", a.Name))
+		o.enabled[a] = enable
+		prefix := a.Name + "."
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			fs.Var(f.Value, prefix+f.Name, f.Usage)
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, false, nil
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	wanted := false
+	for _, name := range driverFlags {
+		wanted = wanted || set[name]
+	}
+	if !wanted {
+		return nil, nil, false, nil
+	}
+	for name := range unsupportedFlags {
+		if set[name] {
+			return nil, nil, true, fmt.Errorf("-%s can't be combined with the flags of the driver", name)
+		}
+	}
+	return o, fs.Args(), true, nil
+}
+
+// enableFlag is the value of a flag enabling an analyzer, which is either
+// unset, true or false, as in multichecker. If any analyzer is enabled, only
+// the enabled analyzers run, and otherwise all but the disabled ones.
+type enableFlag int
+
+const (
+	unset enableFlag = iota
+	setTrue
+	setFalse
+)
+
+func (f *enableFlag) IsBoolFlag() bool { return true }
+
+func (f *enableFlag) String() string {
+	switch *f {
+	case setTrue:
+		return "true"
+	case setFalse:
+		return "false"
+	}
+	return "unset"
+}
+
+func (f *enableFlag) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if b {
+		*f = setTrue
+	} else {
+		*f = setFalse
+	}
+	return nil
+}
+
+// selected returns the analyzers selected by the flags enabling them.
+func (o *driverOptions) selected(analyzers []*analysis.Analyzer) []*analysis.Analyzer {
+	hasTrue := false
+	for _, a := range analyzers {
+		hasTrue = hasTrue || *o.enabled[a] == setTrue
+	}
+	var keep []*analysis.Analyzer
+	for _, a := range analyzers {
+		if hasTrue && *o.enabled[a] == setTrue || !hasTrue && *o.enabled[a] != setFalse {
+			keep = append(keep, a)
+		}
+	}
+	return keep
+}
+
+// A finding is a diagnostic of an analyzer.
+type finding struct {
+	analyzer *analysis.Analyzer
+	// pkg is the ID of the package the diagnostic was reported in.
+	pkg  string
+	fset *token.FileSet
+	posn token.Position
+	// function is the name of the function declaration enclosing the
+	// diagnostic, e.g. 'Client.Do', or empty outside of functions.
+	function   string
+	diagnostic analysis.Diagnostic
+}
+
+// drive runs analyzers on the packages matching patterns and reports the new
+// findings as set by o. It returns the exit code of the command.
+func drive(analyzers []*analysis.Analyzer, o *driverOptions, patterns []string) int {
+	if o.updateBaseline && o.baseline == "" {
+		fmt.Fprintf(os.Stderr, "cedercheck: -update-baseline requires -baseline\n")
+		return 1
+	}
+	if o.diff != "" && o.since != "" {
+		fmt.Fprintf(os.Stderr, "cedercheck: -diff-file and -since are mutually exclusive\n")
+		return 1
+	}
+	if o.format != "text" && o.format != "sarif" {
+		fmt.Fprintf(os.Stderr, "cedercheck: unknown format %q\n", o.format)
+		return 1
+	}
+	if o.json && o.format != "text" {
+		fmt.Fprintf(os.Stderr, "cedercheck: -json and -format are mutually exclusive\n")
+		return 1
+	}
+	analyzers = o.selected(analyzers)
+
+	if o.lsp {
+		if o.baseline != "" || o.diff != "" || o.since != "" || o.format != "text" || o.fix || o.json {
+			fmt.Fprintf(os.Stderr, "cedercheck: -lsp can't be combined with other driver flags\n")
+			return 1
+		}
+		return serveLSP(analyzers, os.Stdin, os.Stdout, o.tests)
+	}
+
+	// Packages are analyzed whole even when filtering by a diff, so that
+	// facts about unchanged code are still exported.
+	var changed changedLines
+	var err error
+	switch {
+	case o.diff != "":
+		changed, err = readDiff(o.diff)
+	case o.since != "":
+		changed, err = diffSince(o.since)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+		return 1
+	}
+
+	findings, analyzed, failed, err := analyze(analyzers, "", patterns, o.tests)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+		return 1
+	}
+	// As in multichecker, the packages whose analysis failed are reported
+	// after analyzing the others.
+	code := 0
+	for _, err := range failed {
+		fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+		code = 1
+	}
+
+	if o.baseline != "" {
+		findings, err = applyBaseline(o.baseline, findings, analyzed, o.updateBaseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+			return 1
+		}
+	}
+
+	// The baseline is applied to all findings, so that those outside of
+	// the diff aren't removed from it.
+	if changed != nil {
+		findings = changed.filter(findings)
+	}
+
+	if o.fix {
+		// Only the findings that remain are fixed, so that neither
+		// the baseline nor unchanged lines are rewritten.
+		if findings, err = applyFixes(findings); err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+			return 1
+		}
+	}
+
+	switch {
+	case o.format == "sarif":
+		// As with the -json flag of multichecker, findings are
+		// output rather than failing the command.
+		if err := writeSARIF(os.Stdout, analyzers, findings); err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+			return 1
+		}
+		return code
+	case o.json:
+		if err := writeJSON(os.Stdout, findings); err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+			return 1
+		}
+		return code
+	}
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", f.posn, f.diagnostic.Message)
+	}
+	if code == 0 && len(findings) != 0 {
+		return 3
+	}
+	return code
+}
+
+// analyzedFiles maps the names of analyzers to the files they analyzed
+// successfully.
+type analyzedFiles map[string]map[string]bool
+
+// analyze runs analyzers on the packages matching patterns in dir, or in the
+// working directory if empty, and returns their findings, sorted by position,
+// the files analyzed and the errors of the analyses that failed.
+func analyze(analyzers []*analysis.Analyzer, dir string, patterns []string, tests bool) ([]*finding, analyzedFiles, []error, error) {
+	cfg := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   dir,
+		Tests: tests,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Analyzers that don't mind type errors run anyway.
+	packages.PrintErrors(pkgs)
+	graph, err := checker.Analyze(analyzers, pkgs, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var findings []*finding
+	var failed []error
+	analyzed, failedFiles := analyzedFiles{}, analyzedFiles{}
+	seen := map[string]bool{}
+	for _, act := range graph.Roots {
+		files := analyzed
+		if act.Err != nil {
+			files = failedFiles
+		}
+		if files[act.Analyzer.Name] == nil {
+			files[act.Analyzer.Name] = map[string]bool{}
+		}
+		for _, file := range act.Package.Syntax {
+			files[act.Analyzer.Name][act.Package.Fset.File(file.Pos()).Name()] = true
+		}
+		if act.Err != nil {
+			// The analysis of other packages, and of this package
+			// by other analyzers, goes on.
+			failed = append(failed, fmt.Errorf("%s: %v", act, act.Err))
+			continue
+		}
+		for _, d := range act.Diagnostics {
+			posn := act.Package.Fset.Position(d.Pos)
+			// Test variants of packages repeat the diagnostics
+			// of their files.
+			key := fmt.Sprintf("%s %s %s", act.Analyzer.Name, posn, d.Message)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, &finding{
+				analyzer:   act.Analyzer,
+				pkg:        act.Package.ID,
+				fset:       act.Package.Fset,
+				posn:       posn,
+				function:   enclosingFunction(act.Package, d.Pos),
+				diagnostic: d,
+			})
+		}
+	}
+	sort.SliceStable(findings, func(ii, jj int) bool {
+		pi, pj := findings[ii].posn, findings[jj].posn
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	// Files of test variants are analyzed with the package they test,
+	// and only count as analyzed if both analyses succeeded.
+	for name, files := range failedFiles {
+		for file := range files {
+			delete(analyzed[name], file)
+		}
+	}
+	return findings, analyzed, failed, nil
+}
+
+// enclosingFunction returns the name of the function declaration of pkg
+// enclosing pos, qualified by the name of its receiver type for methods.
+func enclosingFunction(pkg *packages.Package, pos token.Pos) string {
+	for _, file := range pkg.Syntax {
+		if pos < file.Pos() || pos > file.End() {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || pos < fn.Pos() || pos > fn.End() {
+				continue
+			}
+			if fn.Recv == nil || len(fn.Recv.List) == 0 {
+				return fn.Name.Name
+			}
+			return receiverName(fn.Recv.List[0].Type) + "." + fn.Name.Name
+		}
+	}
+	return ""
+}
+
+// receiverName returns the name of the receiver type expr, without pointers
+// and type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch x := expr.(type) {
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.IndexListExpr:
+			expr = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cederstone/analysis/internal/ignore"
+	"github.com/cederstone/analysis/passes/keyedlit"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestParseDriverFlags(t *testing.T) {
+	var maxDuration time.Duration
+	x := &analysis.Analyzer{Name: "x", Flags: *flag.NewFlagSet("x", flag.ExitOnError)}
+	x.Flags.DurationVar(&maxDuration, "max-duration", 0, "")
+	y := &analysis.Analyzer{Name: "y"}
+	analyzers := []*analysis.Analyzer{x, y}
+	tests := []struct {
+		args []string
+		// wanted is whether the driver runs, with the baseline,
+		// patterns and analyzers below.
+		wanted   bool
+		err      bool
+		baseline string
+		patterns []string
+		selected []*analysis.Analyzer
+		fix      bool
+		// maxDuration is the value set for the flag of x.
+		maxDuration time.Duration
+	}{{
+		args: []string{"./..."},
+	}, {
+		args: []string{"-fix", "-json", "./..."},
+	}, {
+		// -diff is multichecker's, printing fixes as a diff.
+		args: []string{"-fix", "-diff", "./..."},
+	}, {
+		args:     []string{"-fix", "-diff-file=-", "./..."},
+		wanted:   true,
+		patterns: []string{"./..."},
+		selected: analyzers,
+		fix:      true,
+	}, {
+		args:   []string{"-fix", "-diff", "-since=HEAD", "./..."},
+		wanted: true,
+		err:    true,
+	}, {
+		args: []string{"-unknown", "-baseline=b.json", "./..."},
+	}, {
+		args:        []string{"-x.max-duration", "1h", "-baseline=b.json", "./..."},
+		wanted:      true,
+		baseline:    "b.json",
+		patterns:    []string{"./..."},
+		selected:    analyzers,
+		maxDuration: time.Hour,
+	}, {
+		args:        []string{"-baseline", "b.json", "-x.max-duration=1h", "./..."},
+		wanted:      true,
+		baseline:    "b.json",
+		patterns:    []string{"./..."},
+		selected:    analyzers,
+		maxDuration: time.Hour,
+	}, {
+		args:     []string{"-x=false", "-baseline", "b.json", "-fix", "./a", "./b"},
+		wanted:   true,
+		baseline: "b.json",
+		patterns: []string{"./a", "./b"},
+		selected: []*analysis.Analyzer{y},
+		fix:      true,
+	}, {
+		args:     []string{"-y", "-baseline=b.json", "./..."},
+		wanted:   true,
+		baseline: "b.json",
+		patterns: []string{"./..."},
+		selected: []*analysis.Analyzer{y},
+	}, {
+		args:   []string{"-c", "2", "-baseline=b.json", "./..."},
+		wanted: true,
+		err:    true,
+	}}
+	for _, tt := range tests {
+		maxDuration = 0
+		o, patterns, wanted, err := parseDriverFlags(analyzers, tt.args)
+		if wanted != tt.wanted || (err != nil) != tt.err {
+			t.Errorf("parseDriverFlags(%q) = %v, %v, want %v, error %v", tt.args, wanted, err, tt.wanted, tt.err)
+			continue
+		}
+		if !wanted || err != nil {
+			continue
+		}
+		if o.baseline != tt.baseline || o.fix != tt.fix || !reflect.DeepEqual(patterns, tt.patterns) {
+			t.Errorf("parseDriverFlags(%q) = baseline %q, fix %v, patterns %q, want %q, %v, %q", tt.args, o.baseline, o.fix, patterns, tt.baseline, tt.fix, tt.patterns)
+		}
+		if got := o.selected(analyzers); !reflect.DeepEqual(got, tt.selected) {
+			t.Errorf("parseDriverFlags(%q) selects %v, want %v", tt.args, got, tt.selected)
+		}
+		if maxDuration != tt.maxDuration {
+			t.Errorf("parseDriverFlags(%q) sets -x.max-duration to %v, want %v", tt.args, maxDuration, tt.maxDuration)
+		}
+	}
+}
+
+// TestAnalyzeErrors checks that analyzing goes on when an analysis fails.
+func TestAnalyzeErrors(t *testing.T) {
+	failing := &analysis.Analyzer{
+		Name: "failing",
+		Doc:  "fail",
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return nil, errors.New("failed")
+		},
+	}
+	reporting := &analysis.Analyzer{
+		Name: "reporting",
+		Doc:  "report packages",
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			for _, file := range pass.Files {
+				pass.Reportf(file.Package, "package %s", file.Name.Name)
+			}
+			return nil, nil
+		},
+	}
+	dir, err := filepath.Abs(filepath.Join("testdata", "vet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, analyzed, failed, err := analyze([]*analysis.Analyzer{failing, reporting}, dir, []string{"./..."}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 2 {
+		t.Errorf("analyze() failed for %v, want both packages", failed)
+	}
+	var messages []string
+	for _, f := range findings {
+		messages = append(messages, f.diagnostic.Message)
+	}
+	if want := []string{"package app", "package shapes"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("analyze() = %q, want %q", messages, want)
+	}
+	// Only the files analyzed successfully are reported as analyzed.
+	want := analyzedFiles{
+		"reporting": {
+			filepath.Join(dir, "app", "app.go"):       true,
+			filepath.Join(dir, "shapes", "shapes.go"): true,
+		},
+	}
+	if !reflect.DeepEqual(analyzed, want) {
+		t.Errorf("analyze() analyzed %v, want %v", analyzed, want)
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	src := "package a\n\nvar   a, b = 1, 2\n"
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := file.Scope.Lookup("a").Decl.(*ast.ValueSpec).Names[0]
+	newFinding := func(message, name string) *finding {
+		d := analysis.Diagnostic{Pos: a.Pos(), Message: message}
+		if name != "" {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message:   "Rename to " + name,
+				TextEdits: []analysis.TextEdit{{Pos: a.Pos(), End: a.End(), NewText: []byte(name)}},
+			}}
+		}
+		return &finding{fset: fset, diagnostic: d}
+	}
+	findings := []*finding{
+		newFinding("rename", "c"),
+		// Identical edits are applied once, while conflicting
+		// ones are skipped.
+		newFinding("rename again", "c"),
+		newFinding("conflict", "d"),
+		newFinding("no fix", ""),
+	}
+	remaining, err := applyFixes(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := findings[2:]; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("applyFixes() left %v, want %v", remaining, want)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "package a\n\nvar c, b = 1, 2\n"; string(got) != want {
+		t.Errorf("fixed file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestFixMissingFields checks that the fixes of keyedlit adding several
+// fields to a literal, which all insert before its closing brace, apply
+// together.
+func TestFixMissingFields(t *testing.T) {
+	if err := keyedlit.Analyzer.Flags.Set("strict", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer keyedlit.Analyzer.Flags.Set("strict", "false")
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/fields\n\ngo 1.25\n",
+		"fields.go": `package fields
+
+type T struct {
+	A, B, C int
+}
+
+var t = T{
+	A: 1,
+}
+`,
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	findings, _, failed, err := analyze([]*analysis.Analyzer{keyedlit.Analyzer}, dir, []string{"./..."}, false)
+	if err != nil || len(failed) != 0 {
+		t.Fatal(err, failed)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("analyze() = %d findings, want 2", len(findings))
+	}
+	remaining, err := applyFixes(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("applyFixes() left %d findings, want none", len(remaining))
+	}
+	got, err := ioutil.ReadFile(filepath.Join(dir, "fields.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package fields
+
+type T struct {
+	A, B, C int
+}
+
+var t = T{
+	A: 1,
+	B: 0, // TODO: choose B
+	C: 0, // TODO: choose C
+}
+`
+	if string(got) != want {
+		t.Errorf("fixed file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestIgnoreAnalyzers checks that suppressions may name all the analyzers run.
+func TestIgnoreAnalyzers(t *testing.T) {
+	var names []string
+	for _, a := range all {
+		names = append(names, a.Name)
+	}
+	if !reflect.DeepEqual(names, ignore.Analyzers) {
+		t.Errorf("ignore.Analyzers = %q, want %q", ignore.Analyzers, names)
+	}
+}
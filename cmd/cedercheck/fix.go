@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A fileEdit is a text edit of a suggested fix, with byte offsets.
+type fileEdit struct {
+	start, end int
+	text       string
+}
+
+// applyFixes applies the first suggested fix of findings to their files, as
+// the 'fix' flag of multichecker does, and gofmts the files. Identical edits
+// are applied once, and fixes conflicting with a fix applied before are
+// skipped. Insertions at the same offset are applied in the order of
+// findings. It returns the findings that weren't fixed.
+func applyFixes(findings []*finding) ([]*finding, error) {
+	edits := map[string][]fileEdit{}
+	var remaining []*finding
+	for _, f := range findings {
+		if len(f.diagnostic.SuggestedFixes) == 0 {
+			remaining = append(remaining, f)
+			continue
+		}
+		fixEdits := map[string][]fileEdit{}
+		for _, te := range f.diagnostic.SuggestedFixes[0].TextEdits {
+			e := jsonEdit(f, te)
+			fixEdits[e.Filename] = append(fixEdits[e.Filename], fileEdit{e.Start, e.End, e.New})
+		}
+		if conflicts(edits, fixEdits) {
+			remaining = append(remaining, f)
+			continue
+		}
+		for name, fes := range fixEdits {
+		next:
+			for _, fe := range fes {
+				for _, e := range edits[name] {
+					if e == fe {
+						continue next
+					}
+				}
+				edits[name] = append(edits[name], fe)
+			}
+		}
+	}
+	for name, fes := range edits {
+		if err := editFile(name, fes); err != nil {
+			return nil, err
+		}
+	}
+	return remaining, nil
+}
+
+// conflicts reports whether any of fixEdits overlaps a different edit of
+// edits. Insertions only conflict with the edits replacing text around them,
+// so that fixes inserting at the same offset, like missing fields before the
+// closing brace of a literal, all apply.
+func conflicts(edits, fixEdits map[string][]fileEdit) bool {
+	for name, fes := range fixEdits {
+		for _, fe := range fes {
+			for _, e := range edits[name] {
+				if e != fe && fe.start < e.end && e.start < fe.end {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// editFile applies the non-overlapping edits to the file name and gofmts it,
+// unless the edited source doesn't parse.
+func editFile(name string, edits []fileEdit) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	// Insertions come before the replacement starting at their offset,
+	// and in the order they were added otherwise.
+	sort.SliceStable(edits, func(ii, jj int) bool {
+		if edits[ii].start != edits[jj].start {
+			return edits[ii].start < edits[jj].start
+		}
+		return edits[ii].end < edits[jj].end
+	})
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		out = append(out, src[last:e.start]...)
+		out = append(out, e.text...)
+		last = e.end
+	}
+	out = append(out, src[last:]...)
+	if formatted, err := format.Source(out); err == nil {
+		out = formatted
+	}
+	return ioutil.WriteFile(name, out, info.Mode())
+}
+
+// The types below are the JSON output of multichecker, which writeJSON
+// mirrors.
+
+type jsonDiagnostic struct {
+	Category       string              `json:"category,omitempty"`
+	Posn           string              `json:"posn"`
+	End            string              `json:"end"`
+	Message        string              `json:"message"`
+	SuggestedFixes []*jsonSuggestedFix `json:"suggested_fixes,omitempty"`
+	Related        []*jsonRelatedInfo  `json:"related,omitempty"`
+}
+
+type jsonSuggestedFix struct {
+	Message string          `json:"message"`
+	Edits   []*jsonTextEdit `json:"edits"`
+}
+
+type jsonTextEdit struct {
+	Filename string `json:"filename"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	New      string `json:"new"`
+}
+
+type jsonRelatedInfo struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
+// writeJSON writes findings to w as the 'json' flag of multichecker does,
+// keyed by package ID and analyzer name.
+func writeJSON(w io.Writer, findings []*finding) error {
+	tree := map[string]map[string][]*jsonDiagnostic{}
+	for _, f := range findings {
+		d := &jsonDiagnostic{
+			Category: f.diagnostic.Category,
+			Posn:     f.posn.String(),
+			Message:  f.diagnostic.Message,
+		}
+		if f.diagnostic.End.IsValid() {
+			d.End = f.fset.Position(f.diagnostic.End).String()
+		} else {
+			d.End = d.Posn
+		}
+		for _, fix := range f.diagnostic.SuggestedFixes {
+			jf := &jsonSuggestedFix{Message: fix.Message}
+			for _, te := range fix.TextEdits {
+				jf.Edits = append(jf.Edits, jsonEdit(f, te))
+			}
+			d.SuggestedFixes = append(d.SuggestedFixes, jf)
+		}
+		for _, r := range f.diagnostic.Related {
+			d.Related = append(d.Related, &jsonRelatedInfo{
+				Posn:    f.fset.Position(r.Pos).String(),
+				Message: r.Message,
+			})
+		}
+		if tree[f.pkg] == nil {
+			tree[f.pkg] = map[string][]*jsonDiagnostic{}
+		}
+		tree[f.pkg][f.analyzer.Name] = append(tree[f.pkg][f.analyzer.Name], d)
+	}
+	data, err := json.MarshalIndent(tree, "", "\t")
+	if err != nil {
+		return fmt.Errorf("encoding JSON: %v", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// jsonEdit returns the text edit te of the finding f with byte offsets.
+func jsonEdit(f *finding, te analysis.TextEdit) *jsonTextEdit {
+	file := f.fset.File(te.Pos)
+	end := te.End
+	if !end.IsValid() {
+		end = te.Pos
+	}
+	return &jsonTextEdit{
+		Filename: file.Name(),
+		Start:    file.Offset(te.Pos),
+		End:      file.Offset(end),
+		New:      string(te.NewText),
+	}
+}
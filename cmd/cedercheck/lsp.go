@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// serveLSP runs a minimal language server reading requests from in and
+// writing responses to out, as described by the Language Server Protocol
+// (https://microsoft.github.io/language-server-protocol/). It publishes the
+// findings of analyzers in the package of each document opened or saved as
+// diagnostics, and their suggested fixes as quick fix code actions. Packages
+// are analyzed as saved on disk. It returns the exit code of the command.
+func serveLSP(analyzers []*analysis.Analyzer, in io.Reader, out io.Writer, tests bool) int {
+	s := &lspServer{
+		analyzers: analyzers,
+		tests:     tests,
+		out:       out,
+		published: map[string][]*lspFinding{},
+	}
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readMessage(r)
+		if err == io.EOF {
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+			return 1
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		result, rpcErr := s.handle(msg)
+		if msg.ID == nil {
+			// Notifications have no response.
+			continue
+		}
+		resp := &rpcMessage{JSONRPC: "2.0", ID: msg.ID, Error: rpcErr}
+		if rpcErr == nil {
+			if resp.Result, err = json.Marshal(result); err != nil {
+				resp.Result, resp.Error = nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+			}
+		}
+		if err := s.write(resp); err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+			return 1
+		}
+	}
+}
+
+// An rpcMessage is a JSON-RPC 2.0 request, notification or response.
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes defined by JSON-RPC and the Language Server Protocol.
+const (
+	rpcInvalidRequest       = -32600
+	rpcMethodNotFound       = -32601
+	rpcInvalidParams        = -32602
+	rpcInternalError        = -32603
+	lspServerNotInitialized = -32002
+)
+
+// readMessage reads a message preceded by its Content-Length header from r.
+func readMessage(r *bufio.Reader) (*rpcMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	msg := new(rpcMessage)
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %v", err)
+	}
+	return msg, nil
+}
+
+// The types below are the subset of the Language Server Protocol used by
+// lspServer.
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspTextDocument struct {
+	URI string `json:"uri"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspWorkspaceEdit struct {
+	Changes map[string][]*lspTextEdit `json:"changes"`
+}
+
+type lspCodeAction struct {
+	Title       string            `json:"title"`
+	Kind        string            `json:"kind"`
+	Diagnostics []*lspDiagnostic  `json:"diagnostics"`
+	Edit        *lspWorkspaceEdit `json:"edit"`
+}
+
+// lspWarning is the severity of diagnostics.
+const lspWarning = 2
+
+// An lspFinding is a finding published as a diagnostic, with the code
+// actions applying its suggested fixes.
+type lspFinding struct {
+	diagnostic *lspDiagnostic
+	actions    []*lspCodeAction
+}
+
+type lspServer struct {
+	analyzers   []*analysis.Analyzer
+	tests       bool
+	out         io.Writer
+	initialized bool
+	shutdown    bool
+	// published holds the findings published for each document URI.
+	published map[string][]*lspFinding
+}
+
+// handle handles the request or notification msg and returns its result.
+func (s *lspServer) handle(msg *rpcMessage) (interface{}, *rpcError) {
+	if !s.initialized && msg.Method != "initialize" {
+		if msg.ID == nil {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: lspServerNotInitialized, Message: "server not initialized"}
+	}
+	if s.shutdown && msg.ID != nil {
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "server is shut down"}
+	}
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"save":      true,
+				},
+				"codeActionProvider": map[string]interface{}{
+					"codeActionKinds": []string{"quickfix"},
+				},
+			},
+			"serverInfo": map[string]string{"name": "cedercheck"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen", "textDocument/didSave":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		if err := s.check(params.TextDocument.URI); err != nil {
+			s.logMessage(fmt.Sprintf("cedercheck: %v", err))
+		}
+		return nil, nil
+	case "textDocument/codeAction":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+			Range        lspRange        `json:"range"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		actions := []*lspCodeAction{}
+		for _, f := range s.published[params.TextDocument.URI] {
+			if intersect(f.diagnostic.Range, params.Range) {
+				actions = append(actions, f.actions...)
+			}
+		}
+		return actions, nil
+	}
+	if msg.ID == nil {
+		// Other notifications, like 'initialized' and
+		// 'textDocument/didClose', are ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+// check analyzes the package of the document uri and publishes the findings
+// in its files.
+func (s *lspServer) check(uri string) error {
+	path, err := uriPath(uri)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	findings, _, failed, err := analyze(s.analyzers, dir, []string{"file=" + path}, s.tests)
+	if err != nil {
+		return err
+	}
+	for _, err := range failed {
+		s.logMessage(err.Error())
+	}
+
+	content := contentReader()
+	// Documents of the package whose findings were fixed are published
+	// without diagnostics.
+	byURI := map[string][]*lspFinding{}
+	for published := range s.published {
+		if file, err := uriPath(published); err == nil && filepath.Dir(file) == dir {
+			byURI[published] = nil
+		}
+	}
+	for _, f := range findings {
+		lf, err := newLSPFinding(f, content)
+		if err != nil {
+			return err
+		}
+		u := fileURI(f.posn.Filename)
+		byURI[u] = append(byURI[u], lf)
+	}
+	uris := make([]string, 0, len(byURI))
+	for u := range byURI {
+		uris = append(uris, u)
+	}
+	sort.Strings(uris)
+	for _, u := range uris {
+		lfs := byURI[u]
+		diagnostics := []*lspDiagnostic{}
+		for _, lf := range lfs {
+			diagnostics = append(diagnostics, lf.diagnostic)
+		}
+		if len(lfs) == 0 {
+			delete(s.published, u)
+		} else {
+			s.published[u] = lfs
+		}
+		err := s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         u,
+			"diagnostics": diagnostics,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLSPFinding returns the diagnostic and code actions of f, reading the
+// contents of files to convert positions.
+func newLSPFinding(f *finding, content func(file string) ([]byte, error)) (*lspFinding, error) {
+	rng := func(pos, end token.Pos) (lspRange, error) {
+		start := f.fset.Position(pos)
+		stop := start
+		if end.IsValid() {
+			stop = f.fset.Position(end)
+		}
+		data, err := content(start.Filename)
+		if err != nil {
+			return lspRange{}, err
+		}
+		return lspRange{
+			Start: utf16Position(data, start.Offset),
+			End:   utf16Position(data, stop.Offset),
+		}, nil
+	}
+	r, err := rng(f.diagnostic.Pos, f.diagnostic.End)
+	if err != nil {
+		return nil, err
+	}
+	lf := &lspFinding{diagnostic: &lspDiagnostic{
+		Range:    r,
+		Severity: lspWarning,
+		Source:   f.analyzer.Name,
+		Message:  f.diagnostic.Message,
+	}}
+	for _, fix := range f.diagnostic.SuggestedFixes {
+		edit := &lspWorkspaceEdit{Changes: map[string][]*lspTextEdit{}}
+		for _, te := range fix.TextEdits {
+			end := te.End
+			if !end.IsValid() {
+				end = te.Pos
+			}
+			r, err := rng(te.Pos, end)
+			if err != nil {
+				return nil, err
+			}
+			u := fileURI(f.fset.Position(te.Pos).Filename)
+			edit.Changes[u] = append(edit.Changes[u], &lspTextEdit{Range: r, NewText: string(te.NewText)})
+		}
+		lf.actions = append(lf.actions, &lspCodeAction{
+			Title:       fix.Message,
+			Kind:        "quickfix",
+			Diagnostics: []*lspDiagnostic{lf.diagnostic},
+			Edit:        edit,
+		})
+	}
+	return lf, nil
+}
+
+// contentReader returns a function reading the contents of files, once each.
+func contentReader() func(file string) ([]byte, error) {
+	contents := map[string][]byte{}
+	return func(file string) ([]byte, error) {
+		data, ok := contents[file]
+		if !ok {
+			var err error
+			if data, err = ioutil.ReadFile(file); err != nil {
+				return nil, err
+			}
+			contents[file] = data
+		}
+		return data, nil
+	}
+}
+
+// utf16Position returns the position of the byte offset in data, whose
+// characters are counted in UTF-16 code units as in the Language Server
+// Protocol.
+func utf16Position(data []byte, offset int) lspPosition {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	var p lspPosition
+	lineStart := 0
+	for ii := 0; ii < offset; ii++ {
+		if data[ii] == '\n' {
+			p.Line++
+			lineStart = ii + 1
+		}
+	}
+	for line := data[lineStart:offset]; len(line) > 0; {
+		r, size := utf8.DecodeRune(line)
+		if r >= 0x10000 {
+			// A surrogate pair.
+			p.Character += 2
+		} else {
+			p.Character++
+		}
+		line = line[size:]
+	}
+	return p
+}
+
+// intersect reports whether the ranges a and b overlap or touch.
+func intersect(a, b lspRange) bool {
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+func before(p, q lspPosition) bool {
+	return p.Line < q.Line || p.Line == q.Line && p.Character < q.Character
+}
+
+// uriPath returns the path of the file URI.
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI %s", uri)
+	}
+	path := u.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// A Windows path like '/C:/dir'.
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// notify sends the notification method with params.
+func (s *lspServer) notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.write(&rpcMessage{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+// logMessage shows the error message in the client, falling back to
+// standard error.
+func (s *lspServer) logMessage(message string) {
+	const lspError = 1
+	err := s.notify("window/logMessage", map[string]interface{}{
+		"type":    lspError,
+		"message": message,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, message)
+	}
+}
+
+// write writes msg preceded by its Content-Length header.
+func (s *lspServer) write(msg *rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(data)
+	return err
+}
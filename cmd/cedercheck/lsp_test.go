@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cederstone/analysis/passes/nakedreturn"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestLSP(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("testdata", "lsp", "sum.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := fileURI(path)
+	var in bytes.Buffer
+	for ii, msg := range []struct {
+		id     int
+		method string
+		params interface{}
+	}{
+		{1, "initialize", map[string]interface{}{}},
+		{0, "initialized", map[string]interface{}{}},
+		{0, "textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "go", "version": 1, "text": ""},
+		}},
+		{2, "textDocument/codeAction", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"range":        lspRange{Start: lspPosition{Line: 5, Character: 12}, End: lspPosition{Line: 5, Character: 12}},
+			"context":      map[string]interface{}{"diagnostics": []interface{}{}},
+		}},
+		{3, "textDocument/codeAction", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"range":        lspRange{Start: lspPosition{Line: 0}, End: lspPosition{Line: 0}},
+		}},
+		{4, "textDocument/hover", map[string]interface{}{}},
+		{5, "shutdown", nil},
+		{0, "exit", nil},
+	} {
+		m := map[string]interface{}{"jsonrpc": "2.0", "method": msg.method, "params": msg.params}
+		if msg.id != 0 {
+			m["id"] = msg.id
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("message %d: %v", ii, err)
+		}
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+
+	var out bytes.Buffer
+	if code := serveLSP([]*analysis.Analyzer{nakedreturn.Analyzer}, &in, &out, true); code != 0 {
+		t.Errorf("serveLSP() = %d, want 0", code)
+	}
+	responses := map[string]*rpcMessage{}
+	var published []*rpcMessage
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case msg.ID != nil:
+			responses[string(*msg.ID)] = msg
+		case msg.Method == "textDocument/publishDiagnostics":
+			published = append(published, msg)
+		default:
+			t.Errorf("unexpected notification %s %s", msg.Method, msg.Params)
+		}
+	}
+
+	// The naked return follows a comment with characters encoded as
+	// one UTF-16 code unit but two UTF-8 bytes.
+	returnRange := lspRange{Start: lspPosition{Line: 5, Character: 10}, End: lspPosition{Line: 5, Character: 16}}
+	diagnostic := &lspDiagnostic{
+		Range:    returnRange,
+		Severity: lspWarning,
+		Source:   "nakedreturn",
+		Message:  "return values not explicitly specified",
+	}
+	if len(published) != 1 {
+		t.Fatalf("published diagnostics %d times, want once", len(published))
+	}
+	var params struct {
+		URI         string           `json:"uri"`
+		Diagnostics []*lspDiagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(published[0].Params, &params); err != nil {
+		t.Fatal(err)
+	}
+	if params.URI != uri || !reflect.DeepEqual(params.Diagnostics, []*lspDiagnostic{diagnostic}) {
+		t.Errorf("published %s %+v, want %s %+v", params.URI, params.Diagnostics, uri, diagnostic)
+	}
+
+	var actions []*lspCodeAction
+	if err := json.Unmarshal(responses["2"].Result, &actions); err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("code actions = %s, want one", responses["2"].Result)
+	}
+	action := actions[0]
+	if action.Kind != "quickfix" || !reflect.DeepEqual(action.Diagnostics, []*lspDiagnostic{diagnostic}) {
+		t.Errorf("code action is a %s of %+v, want a quickfix of %+v", action.Kind, action.Diagnostics, diagnostic)
+	}
+	edits := action.Edit.Changes[uri]
+	if len(edits) != 1 || edits[0].Range != returnRange || edits[0].NewText != "return sum" {
+		t.Errorf("code action edits %s with %+v, want the return replaced by 'return sum'", uri, edits)
+	}
+
+	if got := string(responses["3"].Result); got != "[]" {
+		t.Errorf("code actions away from the finding = %s, want none", got)
+	}
+	if resp := responses["4"]; resp.Error == nil || resp.Error.Code != rpcMethodNotFound {
+		t.Errorf("unknown method returned %s %+v, want a method not found error", resp.Result, resp.Error)
+	}
+	if got := string(responses["5"].Result); got != "null" {
+		t.Errorf("shutdown returned %s, want null", got)
+	}
+}
+
+func TestUTF16Position(t *testing.T) {
+	data := []byte("a\n«b» 𝄞c\n")
+	for _, tt := range []struct {
+		offset int
+		want   lspPosition
+	}{
+		{0, lspPosition{0, 0}},
+		{2, lspPosition{1, 0}},
+		{4, lspPosition{1, 1}},
+		{8, lspPosition{1, 4}},
+		{13, lspPosition{1, 7}},
+		{100, lspPosition{2, 0}},
+	} {
+		if got := utf16Position(data, tt.offset); got != tt.want {
+			t.Errorf("utf16Position(%d) = %+v, want %+v", tt.offset, got, tt.want)
+		}
+	}
+}
@@ -1,20 +1,102 @@
 // cedercheck runs the analysis passes defined in
 // github.com/cederstone/analysis.
+//
+// The analyzers are configured by the .cedercheck.yaml file at the root of the
+// module containing the working directory, if any.
+//
+// Setting the 'baseline' flag to a file reports only the findings missing from
+// it, which eases adopting the analyzers on existing code. The file is written
+// with all current findings if it doesn't exist, and the 'update-baseline' flag
+// removes the findings that were fixed since.
+//
+// Setting the 'format' flag to 'sarif' writes the findings to standard output
+// as a SARIF 2.1.0 log for code scanning tools, with a rule for each analyzer.
+//
+// Setting the 'diff-file' flag to a unified diff file, or the 'since' flag to
+// a git revision, reports only the findings on the lines changed by the diff,
+// and with 'since' in untracked files. The packages are still analyzed whole. The 'diff' flag is multichecker's,
+// which prints the fixes applied by the 'fix' flag as a diff.
+//
+// Setting the 'lsp' flag runs a minimal language server over standard input
+// and output instead. It publishes the findings in the package of each
+// document opened or saved as diagnostics, and their suggested fixes as code
+// actions.
+//
+// These flags combine with the flags enabling and configuring analyzers, and
+// with the 'fix', 'json' and 'test' flags, in any order. A package whose
+// analysis fails is reported after the others are analyzed.
+//
+// cedercheck can also be run by go vet, with the same config file:
+//
+//	go vet -vettool=$(which cedercheck) ./...
+//
+// 'cedercheck snapshot [packages]' instead writes the lockfile read by the
+// keyedlit pass's 'lockfile' flag.
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/cederstone/analysis/passes/enum"
+	"github.com/cederstone/analysis/passes/funcopts"
 	"github.com/cederstone/analysis/passes/keyedlit"
 	"github.com/cederstone/analysis/passes/nakedreturn"
 	"github.com/cederstone/analysis/passes/union"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/multichecker"
 )
 
+// all are the analyzers of this module.
+var all = []*analysis.Analyzer{
+	enum.Analyzer,
+	funcopts.Analyzer,
+	keyedlit.Analyzer,
+	nakedreturn.Analyzer,
+	union.Analyzer,
+}
+
 func main() {
-	multichecker.Main(
-		enum.Analyzer,
-		keyedlit.Analyzer,
-		nakedreturn.Analyzer,
-		union.Analyzer,
-	)
+	if len(os.Args) > 1 && os.Args[1] == "snapshot" {
+		if err := snapshot(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "cedercheck snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	analyzers, err := configure(all)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+		os.Exit(1)
+	}
+	o, patterns, wanted, err := parseDriverFlags(analyzers, os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cedercheck: %v\n", err)
+		os.Exit(2)
+	}
+	if wanted {
+		os.Exit(drive(analyzers, o, patterns))
+	}
+	// The flags of the driver are listed by -h too.
+	addDriverFlags(flag.CommandLine, new(driverOptions))
+	multichecker.Main(analyzers...)
+}
+
+// configure applies the config file of the module containing the working
+// directory, if any, to analyzers.
+func configure(analyzers []*analysis.Analyzer) ([]*analysis.Analyzer, error) {
+	path, err := findConfig(".")
+	if err != nil || path == "" {
+		return analyzers, err
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	analyzers, err = cfg.apply(analyzers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return analyzers, nil
 }
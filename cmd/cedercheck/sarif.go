@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// The types below are the subset of SARIF 2.1.0 written by writeSARIF. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                         `json:"tool"`
+	OriginalURIBaseIDs map[string]*sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []*sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []*sarifLocation  `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Fixes               []*sarifFix       `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage           `json:"description"`
+	ArtifactChanges []*sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []*sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   *sarifRegion  `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+}
+
+// srcRoot is the base of the URIs of the files in the working directory.
+const srcRoot = "%SRCROOT%"
+
+// fingerprintVersion names the fingerprints of results, and must change with
+// the way they are computed.
+const fingerprintVersion = "cedercheck/v1"
+
+// writeSARIF writes findings of analyzers to w as a SARIF log, with a rule
+// for each analyzer.
+//
+// Results are fingerprinted by their analyzer, file, enclosing function and
+// message rather than by line, as in baselines, so that code scanning tools
+// keep tracking them across unrelated edits. Columns are counted in UTF-16
+// code units, the default of SARIF, so the files of findings are read to
+// compute them.
+func writeSARIF(w io.Writer, analyzers []*analysis.Analyzer, findings []*finding) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	run := &sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cedercheck",
+			InformationURI: "https://github.com/cederstone/analysis",
+			Rules:          []*sarifRule{},
+		}},
+		OriginalURIBaseIDs: map[string]*sarifArtifactLocation{
+			srcRoot: {URI: fileURI(wd) + "/"},
+		},
+		Results: []*sarifResult{},
+	}
+	ruleIndex := map[*analysis.Analyzer]int{}
+	for _, a := range analyzers {
+		ruleIndex[a] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, &sarifRule{
+			ID:               a.Name,
+			ShortDescription: sarifMessage{Text: strings.SplitN(a.Doc, "\n", 2)[0]},
+			FullDescription:  sarifMessage{Text: a.Doc},
+			HelpURI:          a.URL,
+		})
+	}
+
+	content := contentReader()
+	occurrences := map[string]int{}
+	for _, f := range findings {
+		loc := artifactLocation(wd, f.posn.Filename)
+		key := strings.Join([]string{f.analyzer.Name, loc.URI, f.function, f.diagnostic.Message}, "\x00")
+		// Findings with the same key are told apart by their order.
+		occurrences[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, occurrences[key])))
+		r, err := region(f.fset, content, f.diagnostic.Pos, f.diagnostic.End)
+		if err != nil {
+			return err
+		}
+
+		result := &sarifResult{
+			RuleID:    f.analyzer.Name,
+			RuleIndex: ruleIndex[f.analyzer],
+			Level:     "warning",
+			Message:   sarifMessage{Text: f.diagnostic.Message},
+			Locations: []*sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: loc,
+				Region:           r,
+			}}},
+			PartialFingerprints: map[string]string{
+				fingerprintVersion: hex.EncodeToString(sum[:]),
+			},
+		}
+		for _, fix := range f.diagnostic.SuggestedFixes {
+			sf, err := sarifFixOf(wd, f.fset, content, fix)
+			if err != nil {
+				return err
+			}
+			result.Fixes = append(result.Fixes, sf)
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	data, err := json.MarshalIndent(&sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []*sarifRun{run},
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// sarifFixOf returns the SARIF fix of the suggested fix, grouping its edits by
+// file in the order they appear.
+func sarifFixOf(wd string, fset *token.FileSet, content func(file string) ([]byte, error), fix analysis.SuggestedFix) (*sarifFix, error) {
+	sf := &sarifFix{Description: sarifMessage{Text: fix.Message}}
+	changes := map[string]*sarifArtifactChange{}
+	for _, edit := range fix.TextEdits {
+		file := fset.Position(edit.Pos).Filename
+		change, ok := changes[file]
+		if !ok {
+			change = &sarifArtifactChange{ArtifactLocation: artifactLocation(wd, file)}
+			changes[file] = change
+			sf.ArtifactChanges = append(sf.ArtifactChanges, change)
+		}
+		deleted, err := region(fset, content, edit.Pos, edit.End)
+		if err != nil {
+			return nil, err
+		}
+		r := &sarifReplacement{DeletedRegion: deleted}
+		if len(edit.NewText) != 0 {
+			r.InsertedContent = &sarifMessage{Text: string(edit.NewText)}
+		}
+		change.Replacements = append(change.Replacements, r)
+	}
+	return sf, nil
+}
+
+// artifactLocation returns the location of file, relative to the working
+// directory wd if it is within it.
+func artifactLocation(wd, file string) *sarifArtifactLocation {
+	if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return &sarifArtifactLocation{
+			URI:       (&url.URL{Path: filepath.ToSlash(rel)}).String(),
+			URIBaseID: srcRoot,
+		}
+	}
+	return &sarifArtifactLocation{URI: fileURI(file)}
+}
+
+// fileURI returns the file URI of the absolute path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// A Windows path like 'C:/dir'.
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// region returns the region between pos and end, which may be invalid for an
+// empty region at pos, reading the contents of its file to count columns in
+// UTF-16 code units.
+func region(fset *token.FileSet, content func(file string) ([]byte, error), pos, end token.Pos) (*sarifRegion, error) {
+	start := fset.Position(pos)
+	stop := start
+	if end.IsValid() {
+		stop = fset.Position(end)
+	}
+	data, err := content(start.Filename)
+	if err != nil {
+		return nil, err
+	}
+	// Lines and columns are 1-based, unlike LSP positions.
+	from, to := utf16Position(data, start.Offset), utf16Position(data, stop.Offset)
+	return &sarifRegion{
+		StartLine:   start.Line,
+		StartColumn: from.Character + 1,
+		EndLine:     stop.Line,
+		EndColumn:   to.Character + 1,
+	}, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// sarifFindings writes src to pkg/a.go in the working directory and returns
+// the findings of a, at each return statement of src, with a fix removing the
+// statement.
+func sarifFindings(t *testing.T, a *analysis.Analyzer, src string) []*finding {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(wd, "pkg", "a.go")
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var findings []*finding
+	for _, off := range indices(src, "return") {
+		pos := fset.File(file.Pos()).Pos(off)
+		end := pos + token.Pos(len("return"))
+		findings = append(findings, &finding{
+			analyzer: a,
+			fset:     fset,
+			posn:     fset.Position(pos),
+			function: "F",
+			diagnostic: analysis.Diagnostic{
+				Pos:     pos,
+				End:     end,
+				Message: "bare return",
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message:   "Remove the return",
+					TextEdits: []analysis.TextEdit{{Pos: pos, End: end}},
+				}},
+			},
+		})
+	}
+	return findings
+}
+
+func indices(s, substr string) []int {
+	var offsets []int
+	for off := 0; ; {
+		ii := strings.Index(s[off:], substr)
+		if ii < 0 {
+			return offsets
+		}
+		offsets = append(offsets, off+ii)
+		off += ii + len(substr)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	t.Chdir(t.TempDir())
+	a := &analysis.Analyzer{
+		Name: "a",
+		Doc:  "check a thing.\n\nMore about the thing.",
+		URL:  "https://example.com/a",
+	}
+	b := &analysis.Analyzer{Name: "b", Doc: "check another thing."}
+
+	decode := func(findings []*finding) *sarifLog {
+		var buf bytes.Buffer
+		if err := writeSARIF(&buf, []*analysis.Analyzer{a, b}, findings); err != nil {
+			t.Fatal(err)
+		}
+		log := new(sarifLog)
+		if err := json.Unmarshal(buf.Bytes(), log); err != nil {
+			t.Fatalf("writeSARIF() wrote invalid JSON: %v\n%s", err, buf.Bytes())
+		}
+		return log
+	}
+
+	log := decode(sarifFindings(t, a, "package p\n\nfunc F() {\n\treturn\n\treturn\n}\n"))
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("writeSARIF() wrote version %s with %d runs, want 2.1.0 with 1 run", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	wantRules := []*sarifRule{{
+		ID:               "a",
+		ShortDescription: sarifMessage{Text: "check a thing."},
+		FullDescription:  sarifMessage{Text: a.Doc},
+		HelpURI:          a.URL,
+	}, {
+		ID:               "b",
+		ShortDescription: sarifMessage{Text: "check another thing."},
+		FullDescription:  sarifMessage{Text: b.Doc},
+	}}
+	if !reflect.DeepEqual(run.Tool.Driver.Rules, wantRules) {
+		t.Errorf("rules = %+v, want %+v", run.Tool.Driver.Rules, wantRules)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("writeSARIF() wrote %d results, want 2", len(run.Results))
+	}
+	result := run.Results[0]
+	wantLoc := &sarifArtifactLocation{URI: "pkg/a.go", URIBaseID: srcRoot}
+	wantRegion := &sarifRegion{StartLine: 4, StartColumn: 2, EndLine: 4, EndColumn: 8}
+	if result.RuleID != "a" || result.RuleIndex != 0 {
+		t.Errorf("result is of rule %s at %d, want a at 0", result.RuleID, result.RuleIndex)
+	}
+	if loc := result.Locations[0].PhysicalLocation; !reflect.DeepEqual(loc.ArtifactLocation, wantLoc) || !reflect.DeepEqual(loc.Region, wantRegion) {
+		t.Errorf("result is at %+v %+v, want %+v %+v", loc.ArtifactLocation, loc.Region, wantLoc, wantRegion)
+	}
+	wantFixes := []*sarifFix{{
+		Description: sarifMessage{Text: "Remove the return"},
+		ArtifactChanges: []*sarifArtifactChange{{
+			ArtifactLocation: wantLoc,
+			Replacements:     []*sarifReplacement{{DeletedRegion: wantRegion}},
+		}},
+	}}
+	if !reflect.DeepEqual(result.Fixes, wantFixes) {
+		t.Errorf("fixes = %+v, want %+v", result.Fixes, wantFixes)
+	}
+
+	// Fingerprints tell findings with the same key apart, but don't
+	// change when they move.
+	first, second := run.Results[0].PartialFingerprints, run.Results[1].PartialFingerprints
+	if reflect.DeepEqual(first, second) {
+		t.Errorf("findings with the same key have the same fingerprints %v", first)
+	}
+	moved := decode(sarifFindings(t, a, "package p\n\n// F does nothing.\nfunc F() {\n\treturn\n\treturn\n}\n")).Runs[0]
+	if got := moved.Results[0].PartialFingerprints; !reflect.DeepEqual(got, first) {
+		t.Errorf("fingerprints of a moved finding = %v, want %v", got, first)
+	}
+}
+
+// TestSARIFColumns checks that columns are counted in UTF-16 code units.
+func TestSARIFColumns(t *testing.T) {
+	t.Chdir(t.TempDir())
+	a := &analysis.Analyzer{Name: "a", Doc: "check a thing."}
+	// "é" is 2 bytes and 1 code unit, "𝄞" 4 bytes and 2 code units, so the
+	// return is at byte column 16 but at column 13.
+	findings := sarifFindings(t, a, "package p\n\nfunc F() {\n\t_ = \"é𝄞\"; return\n}\n")
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, []*analysis.Analyzer{a}, findings); err != nil {
+		t.Fatal(err)
+	}
+	log := new(sarifLog)
+	if err := json.Unmarshal(buf.Bytes(), log); err != nil {
+		t.Fatal(err)
+	}
+	result := log.Runs[0].Results[0]
+	want := &sarifRegion{StartLine: 4, StartColumn: 13, EndLine: 4, EndColumn: 19}
+	if got := result.Locations[0].PhysicalLocation.Region; !reflect.DeepEqual(got, want) {
+		t.Errorf("region = %+v, want %+v", got, want)
+	}
+	if got := result.Fixes[0].ArtifactChanges[0].Replacements[0].DeletedRegion; !reflect.DeepEqual(got, want) {
+		t.Errorf("deleted region = %+v, want %+v", got, want)
+	}
+}
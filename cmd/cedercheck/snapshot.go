@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cederstone/analysis/passes/keyedlit/lockfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// snapshot implements 'cedercheck snapshot', which writes the lockfile read
+// by the keyedlit pass's 'lockfile' flag.
+func snapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	output := fs.String("o", lockfile.DefaultPath, "lockfile to write")
+	tests := fs.Bool("tests", false, "include test files")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: cedercheck snapshot [flags] [packages]\n\n")
+		fmt.Fprintf(os.Stderr, "Snapshot records the fields of the struct types used in keyed literals so that\n")
+		fmt.Fprintf(os.Stderr, "'cedercheck -keyedlit.lockfile' can report types that gained fields since.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+		Tests: *tests,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return fmt.Errorf("failed to load packages")
+	}
+	return lockfile.Snapshot(pkgs).Write(*output)
+}
@@ -0,0 +1,6 @@
+package fix
+
+func split(s string) (head, tail string) {
+	head, tail = s[:1], s[1:]
+	return
+}
@@ -0,0 +1,7 @@
+package lsp
+
+// Sum returns the sum of a and b, e.g. «1 + 2».
+func Sum(a, b int) (sum int) {
+	sum = a + b
+	/* «» */ return
+}
@@ -0,0 +1 @@
+package legacy // want "mode legacy"
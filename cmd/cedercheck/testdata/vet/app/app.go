@@ -0,0 +1,11 @@
+package app
+
+import "example.com/vet/shapes"
+
+func Area(s shapes.Shape) int {
+	switch s.(type) {
+	case *shapes.Circle:
+		return 1
+	}
+	return 0
+}
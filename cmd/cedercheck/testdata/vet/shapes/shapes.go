@@ -0,0 +1,13 @@
+package shapes
+
+type Shape interface{ shape() }
+
+type Circle struct{}
+
+func (*Circle) shape() {}
+
+type Square struct{}
+
+func (*Square) shape() {}
+
+var _ = []Shape{&Circle{}, &Square{}}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVetTool checks that cedercheck runs as 'go vet -vettool', which
+// serializes the facts exported for each package.
+func TestVetTool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds cedercheck and runs go vet")
+	}
+	tool := build(t)
+	vet := exec.Command("go", "vet", "-vettool="+tool, "./...")
+	vet.Dir = filepath.Join("testdata", "vet")
+	out, err := vet.CombinedOutput()
+	if err == nil {
+		t.Fatalf("go vet succeeded, want a failure\n%s", out)
+	}
+	// The union is declared in another package than the switch.
+	want := "app/app.go:6:2: non-total type switch over union: missing *example.com/vet/shapes.Square"
+	if !strings.Contains(string(out), want) {
+		t.Errorf("go vet wrote\n%s\nwant %s", out, want)
+	}
+}
+
+// TestFixDiff checks that the 'diff' flag of multichecker prints the fixes
+// of the 'fix' flag rather than being taken for the 'diff-file' flag.
+func TestFixDiff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds cedercheck")
+	}
+	cmd := exec.Command(build(t), "-fix", "-diff", "./...")
+	cmd.Dir = filepath.Join("testdata", "fix")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("cedercheck -fix -diff: %v\n%s", err, out)
+	}
+	want := "-\treturn\n+\treturn head, tail\n"
+	if !strings.Contains(string(out), want) {
+		t.Errorf("cedercheck -fix -diff wrote\n%s\nwant %s", out, want)
+	}
+}
+
+// build builds cedercheck and returns the path of the binary.
+func build(t *testing.T) string {
+	tool := filepath.Join(t.TempDir(), "cedercheck")
+	if out, err := exec.Command("go", "build", "-o", tool, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return tool
+}
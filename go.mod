@@ -1,5 +1,14 @@
 module github.com/cederstone/analysis
 
-go 1.12
+go 1.25.0
 
-require golang.org/x/tools v0.0.0-20190416194154-76038274be7a
+require (
+	github.com/golangci/plugin-module-register v0.1.2
+	golang.org/x/tools v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+)
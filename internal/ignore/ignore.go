@@ -0,0 +1,311 @@
+// Package ignore implements the suppression comments honored by all the
+// analyzers of this module.
+//
+// A suppression names the analyzers whose diagnostics it suppresses, separated
+// by commas, and the reason for suppressing them:
+//
+//	//cederstone:ignore keyedlit,nakedreturn the defaults are fine here
+//
+// It suppresses the diagnostics on its own line and, if it is on a line of its
+// own, on the next line. It also suppresses the diagnostics within the
+// statement or declaration starting on either line, so that a suppression
+// above a statement covers all of it. Suppressions that suppress nothing,
+// suppressions without a reason and suppressions naming an unknown analyzer
+// are reported.
+package ignore
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Directive starts a suppression comment.
+const Directive = "//cederstone:ignore"
+
+// Analyzers are the names of the analyzers of this module, which suppressions
+// may name.
+var Analyzers = []string{"enum", "funcopts", "keyedlit", "nakedreturn", "union"}
+
+// Run wraps the run function of an analyzer so that its diagnostics are
+// filtered by the suppressions naming it:
+//
+//	var Analyzer = &analysis.Analyzer{
+//		Name: "example",
+//		Run:  ignore.Run(run),
+//	}
+func Run(run func(*analysis.Pass) (interface{}, error)) func(*analysis.Pass) (interface{}, error) {
+	return RunExamining(run, nil)
+}
+
+// RunExamining is like Run for analyzers that only examine the files of a
+// package for which examines reports true, e.g. because test files are
+// skipped. The suppressions in other files are neither checked for a reason
+// nor reported as suppressing nothing. A nil examines examines all files.
+func RunExamining(run func(*analysis.Pass) (interface{}, error), examines func(*analysis.Pass, *ast.File) bool) func(*analysis.Pass) (interface{}, error) {
+	return func(pass *analysis.Pass) (interface{}, error) {
+		suppressions := find(pass, examines)
+		if len(suppressions) == 0 {
+			return run(pass)
+		}
+		filtered := *pass
+		filtered.Report = func(d analysis.Diagnostic) {
+			for _, s := range suppressions {
+				if s.covers(d.Pos) {
+					s.used = true
+					return
+				}
+			}
+			pass.Report(d)
+		}
+		result, err := run(&filtered)
+		if err != nil {
+			return result, err
+		}
+		for _, s := range suppressions {
+			switch {
+			case s.reason == "":
+				pass.Reportf(s.pos, "suppression of %s diagnostics lacks a reason", pass.Analyzer.Name)
+			case !s.used:
+				pass.Reportf(s.pos, "suppression of %s diagnostics suppresses nothing", pass.Analyzer.Name)
+			}
+		}
+		return result, nil
+	}
+}
+
+// A suppression is a suppression comment naming the analyzer being run.
+type suppression struct {
+	pos    token.Pos
+	reason string
+	// The suppressed diagnostics are those on lines, or between start
+	// and end.
+	file       *token.File
+	lines      []int
+	start, end token.Pos
+	used       bool
+}
+
+func (s *suppression) covers(pos token.Pos) bool {
+	if s.start <= pos && pos < s.end {
+		return true
+	}
+	if s.file.Base() > int(pos) || int(pos) > s.file.Base()+s.file.Size() {
+		return false
+	}
+	line := s.file.Line(pos)
+	for _, l := range s.lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the suppressions of the files of pass examined by the analyzer
+// being run that name it.
+func find(pass *analysis.Pass, examines func(*analysis.Pass, *ast.File) bool) []*suppression {
+	var suppressions []*suppression
+	for _, file := range pass.Files {
+		tf := pass.Fset.File(file.Pos())
+		if tf == nil || examines != nil && !examines(pass, file) {
+			continue
+		}
+		var inFile []*suppression
+		for _, cg := range file.Comments {
+			for _, c := range cg.List {
+				for _, msg := range unknown(pass.Analyzer.Name, c) {
+					pass.Reportf(c.Pos(), "%s", msg)
+				}
+				s, ok := parse(pass.Analyzer.Name, c)
+				if !ok {
+					continue
+				}
+				s.file = tf
+				line := tf.Line(c.Pos())
+				s.lines = []int{line}
+				if ownLine(tf, file, c) {
+					s.lines = append(s.lines, line+1)
+				}
+				inFile = append(inFile, s)
+			}
+		}
+		if len(inFile) == 0 {
+			continue
+		}
+		// Find the outermost statement or declaration starting on
+		// the lines of each suppression.
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n.(type) {
+			case ast.Stmt, ast.Decl, ast.Spec, *ast.Field:
+			default:
+				return true
+			}
+			if _, ok := n.(*ast.BlockStmt); ok {
+				return true
+			}
+			line := tf.Line(n.Pos())
+			for _, s := range inFile {
+				if s.start.IsValid() {
+					continue
+				}
+				for _, l := range s.lines {
+					if l == line {
+						s.start, s.end = n.Pos(), n.End()
+					}
+				}
+			}
+			return true
+		})
+		suppressions = append(suppressions, inFile...)
+	}
+	return suppressions
+}
+
+// parse returns the suppression of c if it is a suppression comment naming
+// analyzer.
+func parse(analyzer string, c *ast.Comment) (*suppression, bool) {
+	if c.Text != Directive && !strings.HasPrefix(c.Text, Directive+" ") {
+		return nil, false
+	}
+	fields := strings.Fields(strings.TrimPrefix(c.Text, Directive))
+	if len(fields) == 0 {
+		return nil, false
+	}
+	named := false
+	for _, name := range strings.Split(fields[0], ",") {
+		if name == analyzer {
+			named = true
+		}
+	}
+	if !named {
+		return nil, false
+	}
+	return &suppression{
+		pos:    c.Pos(),
+		reason: strings.Join(fields[1:], " "),
+	}, true
+}
+
+// unknown returns the messages reporting the unknown analyzers named by c if
+// it is a suppression comment. Each directive is reported by one analyzer
+// only: the first known analyzer it names or, if it names none, the analyzer
+// whose name is closest to the first unknown name, which is likely the one
+// the directive meant to name.
+func unknown(analyzer string, c *ast.Comment) []string {
+	if c.Text != Directive && !strings.HasPrefix(c.Text, Directive+" ") {
+		return nil
+	}
+	fields := strings.Fields(strings.TrimPrefix(c.Text, Directive))
+	if len(fields) == 0 {
+		return nil
+	}
+	var names []string
+	reporter := ""
+	for _, name := range strings.Split(fields[0], ",") {
+		if known(name) {
+			if reporter == "" {
+				reporter = name
+			}
+		} else {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if reporter == "" {
+		reporter = closest(names[0])
+	}
+	if reporter != analyzer {
+		return nil
+	}
+	var msgs []string
+	for _, name := range names {
+		msg := fmt.Sprintf("suppression names unknown analyzer %q", name)
+		if c := closest(name); distance(name, c) <= 2 {
+			msg += fmt.Sprintf("; did you mean %q?", c)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func known(name string) bool {
+	for _, a := range Analyzers {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// closest returns the first of Analyzers with the least edit distance to name.
+func closest(name string) string {
+	best, least := "", -1
+	for _, a := range Analyzers {
+		if d := distance(name, a); least < 0 || d < least {
+			best, least = a, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minInt(x int, ys ...int) int {
+	for _, y := range ys {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
+
+// ownLine reports whether the comment c is on a line of its own, as opposed
+// to following code on its line.
+func ownLine(tf *token.File, file *ast.File, c *ast.Comment) bool {
+	line := tf.Line(c.Pos())
+	own := true
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || !own {
+			return false
+		}
+		if _, ok := n.(*ast.File); ok {
+			return true
+		}
+		if n.Pos() > c.Pos() || n.End() < tf.LineStart(line) {
+			// The node is after the comment or ends before its
+			// line.
+			return false
+		}
+		if n.End() <= c.Pos() && tf.Line(n.End()) == line {
+			own = false
+			return false
+		}
+		return true
+	})
+	return own
+}
@@ -0,0 +1,38 @@
+package ignore_test
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/cederstone/analysis/internal/ignore"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// analyzer reports all calls of functions named bad.
+var analyzer = &analysis.Analyzer{
+	Name: "bad",
+	Doc:  "report calls of bad",
+	Run: ignore.Run(func(pass *analysis.Pass) (interface{}, error) {
+		for _, file := range pass.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "bad" {
+					pass.Reportf(call.Pos(), "call of bad")
+				}
+				return true
+			})
+		}
+		return nil, nil
+	}),
+}
+
+func TestIgnore(t *testing.T) {
+	defer func(analyzers []string) { ignore.Analyzers = analyzers }(ignore.Analyzers)
+	ignore.Analyzers = []string{"bad", "other"}
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer, "a") // loads testdata/src/a/a.go.
+}
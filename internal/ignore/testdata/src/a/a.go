@@ -0,0 +1,45 @@
+package a
+
+func bad() int { return 0 }
+
+func calls() {
+	bad() // want "call of bad"
+
+	bad() //cederstone:ignore bad known to be bad
+
+	//cederstone:ignore bad the whole statement is suppressed
+	if bad() == 0 {
+		bad()
+	}
+
+	//cederstone:ignore other,bad several analyzers can be named
+	bad()
+
+	//cederstone:ignore other suppressions of other analyzers are left alone
+	bad() // want "call of bad"
+
+	/* want "suppression of bad diagnostics lacks a reason" */ //cederstone:ignore bad
+	bad()
+
+	/* want "suppression of bad diagnostics suppresses nothing" */ //cederstone:ignore bad nothing to suppress
+	_ = 1
+
+	bad() // want "call of bad"
+
+	bad() /* want "call of bad" `suppression names unknown analyzer "bda"; did you mean "bad"\?` */ //cederstone:ignore bda misspelled analyzers suppress nothing
+
+	/* want `suppression names unknown analyzer "unknown"$` */ //cederstone:ignore bad,unknown known analyzers are still suppressed
+	bad()
+
+	// Unknown analyzers are reported by the first known analyzer named.
+	//cederstone:ignore other,unknown left to the other analyzer
+	bad() // want "call of bad"
+}
+
+//cederstone:ignore bad the whole function is suppressed
+func suppressed() {
+	bad()
+	bad()
+}
+
+var _ = bad() //cederstone:ignore bad in a declaration
@@ -0,0 +1,39 @@
+// Package pattern matches package paths and type names against the patterns
+// of configuration files, in which '...' matches any string, as in the go
+// command.
+package pattern
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	compiledMu sync.Mutex
+	compiled   = map[string]*regexp.Regexp{}
+)
+
+// Match reports whether s matches pattern, in which '...' matches any string.
+// As in the go command, a pattern ending in '/...' also matches the string
+// without that suffix.
+func Match(pattern, s string) bool {
+	if strings.HasSuffix(pattern, "/...") && s == strings.TrimSuffix(pattern, "/...") {
+		return true
+	}
+	return compile(pattern).MatchString(s)
+}
+
+// compile returns the regular expression of pattern, compiling it on first
+// use.
+func compile(pattern string) *regexp.Regexp {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	re, ok := compiled[pattern]
+	if !ok {
+		// The quoted pattern is always a valid regular expression.
+		re = regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(pattern), `\.\.\.`, `.*`, -1) + "$")
+		compiled[pattern] = re
+	}
+	return re
+}
@@ -0,0 +1,32 @@
+package pattern_test
+
+import (
+	"testing"
+
+	"github.com/cederstone/analysis/internal/pattern"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"net/http", "net/http", true},
+		{"net/http", "net/http/httptest", false},
+		{"net/...", "net", true},
+		{"net/...", "net/http", true},
+		{"net/...", "network", false},
+		{"net...", "network", true},
+		{"example.com/.../config", "example.com/a/b/config", true},
+		{"example.com/.../config", "example.com/a/b/configs", false},
+		{"a.b", "axb", false},
+	}
+	for _, test := range tests {
+		for ii := 0; ii < 2; ii++ {
+			// The second match uses the cached expression.
+			if got := pattern.Match(test.pattern, test.s); got != test.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", test.pattern, test.s, got, test.want)
+			}
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"go/token"
 	"go/types"
 
+	"github.com/cederstone/analysis/internal/ignore"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -27,7 +28,7 @@ var Analyzer = &analysis.Analyzer{
 	Name:             "enum",
 	Doc:              Doc,
 	Requires:         []*analysis.Analyzer{inspect.Analyzer},
-	Run:              run,
+	Run:              ignore.Run(run),
 	RunDespiteErrors: true,
 }
 
@@ -65,4 +65,8 @@ func main() {
 	case FooD1:
 	case FooD2:
 	}
+	//cederstone:ignore enum only Foo1 is ever stored
+	switch a {
+	case Foo1:
+	}
 }
@@ -0,0 +1,8 @@
+package main
+
+import (
+	"github.com/cederstone/analysis/passes/funcopts"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() { singlechecker.Main(funcopts.Analyzer) }
@@ -0,0 +1,362 @@
+// Package funcopts defines an analysis pass that checks that calls of
+// functional-options constructors pass the options they require.
+//
+// The keyedlit pass checks that important fields of struct literals are
+// specified. Many libraries instead hide their configuration behind
+// constructors taking variadic options:
+//
+//	func NewClient(addr string, opts ...Option) *Client
+//
+// A constructor declares the options it requires with a directive in its doc
+// comment, naming option functions or option types. Names are resolved in the
+// constructor's package, or in a package imported by its file when qualified,
+// like 'retry.WithPolicy'. Alternatives that each satisfy a requirement are
+// separated by '|':
+//
+//	// NewClient returns a client of the server at addr.
+//	//
+//	//cederstone:requires WithTimeout|WithDeadline WithRetries
+//	func NewClient(addr string, opts ...Option) *Client
+//
+// Calls of the constructor in any package must pass a call of each required
+// option function, the function itself, or a value of each required option
+// type as an argument. The options are the variadic arguments and the
+// arguments of the type of the variadic parameter or of a required option
+// type. Options may be stored in variables that are assigned once. Calls that
+// spread a slice of options, like 'NewClient(addr, opts...)', or pass options
+// that can't be resolved, like parameters, aren't checked.
+package funcopts
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/cederstone/analysis/internal/ignore"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `check that calls of functional-options constructors pass required options.
+
+A constructor declares the option functions or types that must be passed to it
+with a '//cederstone:requires WithTimeout' directive in its doc comment.
+Alternatives are separated by '|'.`
+
+// requiresDirective declares the options a constructor requires.
+const requiresDirective = "//cederstone:requires"
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "funcopts",
+	Doc:              Doc,
+	Requires:         []*analysis.Analyzer{inspect.Analyzer},
+	Run:              ignore.Run(run),
+	RunDespiteErrors: true,
+	FactTypes:        []analysis.Fact{new(requires)},
+}
+
+// requires is the fact that a function requires options. Each requirement
+// lists the full names of the alternative option functions or types, e.g.
+// 'example.com/client.WithTimeout'.
+type requires struct {
+	Options [][]string
+}
+
+func (*requires) AFact() {}
+
+func (r *requires) String() string {
+	var reqs []string
+	for _, alts := range r.Options {
+		reqs = append(reqs, strings.Join(alts, "|"))
+	}
+	return "requires(" + strings.Join(reqs, " ") + ")"
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		exportRequires(pass, n.(*ast.FuncDecl))
+	})
+
+	values := assignedOnce(pass)
+	nodeFilter = []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		checkCall(pass, values, n.(*ast.CallExpr))
+	})
+	return nil, nil
+}
+
+// exportRequires exports the options required by the directive in the doc
+// comment of decl, if any.
+func exportRequires(pass *analysis.Pass, decl *ast.FuncDecl) {
+	if decl.Doc == nil {
+		return
+	}
+	fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	fact := new(requires)
+	for _, c := range decl.Doc.List {
+		if c.Text != requiresDirective && !strings.HasPrefix(c.Text, requiresDirective+" ") {
+			continue
+		}
+		args := strings.Fields(strings.TrimPrefix(c.Text, requiresDirective))
+		if len(args) == 0 {
+			pass.Reportf(c.Pos(), "%s directive lists no options", requiresDirective)
+			continue
+		}
+		for _, arg := range args {
+			var alts []string
+			for _, name := range strings.Split(arg, "|") {
+				opt := lookupOption(pass, decl, name)
+				if opt == nil {
+					pass.Reportf(c.Pos(), "required option %s is not a function or type of package %s", name, pass.Pkg.Name())
+					continue
+				}
+				alts = append(alts, opt.Pkg().Path()+"."+opt.Name())
+			}
+			if len(alts) != 0 {
+				fact.Options = append(fact.Options, alts)
+			}
+		}
+	}
+	if len(fact.Options) != 0 {
+		pass.ExportObjectFact(fn, fact)
+	}
+}
+
+// lookupOption returns the option function or type named name in the
+// directive of decl. Names qualified by a package name refer to the packages
+// imported by the file of decl.
+func lookupOption(pass *analysis.Pass, decl *ast.FuncDecl, name string) types.Object {
+	scope := pass.Pkg.Scope()
+	if dot := strings.Index(name, "."); dot >= 0 {
+		scope = nil
+		for _, file := range pass.Files {
+			if file.Pos() > decl.Pos() || decl.Pos() >= file.End() {
+				continue
+			}
+			for _, spec := range file.Imports {
+				if pkgName := pass.TypesInfo.PkgNameOf(spec); pkgName != nil && pkgName.Name() == name[:dot] {
+					scope = pkgName.Imported().Scope()
+				}
+			}
+		}
+		if scope == nil {
+			return nil
+		}
+		name = name[dot+1:]
+	}
+	switch obj := scope.Lookup(name).(type) {
+	case *types.Func:
+		return obj
+	case *types.TypeName:
+		if !obj.IsAlias() {
+			return obj
+		}
+	}
+	return nil
+}
+
+// checkCall reports the required options missing from call.
+func checkCall(pass *analysis.Pass, values map[*types.Var]ast.Expr, call *ast.CallExpr) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok {
+		return
+	}
+	fact := new(requires)
+	if !pass.ImportObjectFact(fn.Origin(), fact) {
+		return
+	}
+	if call.Ellipsis.IsValid() {
+		// The options are spread from a slice that can't be
+		// checked.
+		return
+	}
+	required := map[string]bool{}
+	for _, alts := range fact.Options {
+		for _, name := range alts {
+			required[name] = true
+		}
+	}
+	passed := map[string]bool{}
+	for _, arg := range optionArgs(pass.TypesInfo, fn.Type().(*types.Signature), required, call) {
+		name, ok := option(pass.TypesInfo, values, required, arg)
+		if !ok {
+			// The option can't be resolved, and may be any of
+			// the required ones.
+			return
+		}
+		passed[name] = true
+	}
+	for _, alts := range fact.Options {
+		satisfied := false
+		for _, name := range alts {
+			if passed[name] {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			pass.Reportf(call.Lparen, "call of %s is missing required option %s", fn.Name(), optionNames(alts))
+		}
+	}
+}
+
+// optionArgs returns the arguments of call that pass options: the variadic
+// arguments of sig, and the arguments of the type of its variadic parameter
+// or of a required option type. Other arguments, like an address, aren't
+// resolved.
+func optionArgs(info *types.Info, sig *types.Signature, required map[string]bool, call *ast.CallExpr) []ast.Expr {
+	variadic := -1
+	var elem types.Type
+	if sig.Variadic() {
+		variadic = sig.Params().Len() - 1
+		elem = sig.Params().At(variadic).Type().(*types.Slice).Elem()
+	}
+	var args []ast.Expr
+	for ii, arg := range call.Args {
+		switch {
+		case variadic >= 0 && ii >= variadic:
+		case elem != nil && ii < sig.Params().Len() && types.Identical(sig.Params().At(ii).Type(), elem):
+		case required[typeName(info.TypeOf(arg))]:
+		default:
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// option returns the full name of the option function or required option
+// type that arg passes, or the empty string for other values. Variables are
+// resolved through values. It reports false if arg can't be resolved.
+func option(info *types.Info, values map[*types.Var]ast.Expr, required map[string]bool, arg ast.Expr) (string, bool) {
+	arg = ast.Unparen(arg)
+	if name := typeName(info.TypeOf(arg)); required[name] {
+		return name, true
+	}
+	if fn := optionFunc(info, arg); fn != nil && fn.Type().(*types.Signature).Recv() == nil {
+		return fn.Origin().FullName(), true
+	}
+	var v *types.Var
+	switch x := arg.(type) {
+	case *ast.Ident:
+		v, _ = info.Uses[x].(*types.Var)
+	case *ast.SelectorExpr:
+		v, _ = info.Uses[x.Sel].(*types.Var)
+	case *ast.CallExpr:
+		if tv, ok := info.Types[x.Fun]; ok && tv.IsType() {
+			// A conversion to a type that isn't an option.
+			return "", true
+		}
+		// A call of a method or a function value may return any
+		// option.
+		return "", false
+	}
+	if v != nil {
+		value, ok := values[v]
+		if !ok {
+			return "", false
+		}
+		return option(info, values, required, value)
+	}
+	return "", true
+}
+
+// typeName returns the package path and name of the named type t, or of the
+// named type it points to.
+func typeName(t types.Type) string {
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = types.Unalias(ptr.Elem())
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
+
+// optionFunc returns the function creating the option arg, which is either a
+// call of the function or the function itself.
+func optionFunc(info *types.Info, arg ast.Expr) *types.Func {
+	var fn *types.Func
+	switch x := ast.Unparen(arg).(type) {
+	case *ast.CallExpr:
+		fn, _ = typeutil.Callee(info, x).(*types.Func)
+	case *ast.Ident:
+		fn, _ = info.Uses[x].(*types.Func)
+	case *ast.SelectorExpr:
+		fn, _ = info.Uses[x.Sel].(*types.Func)
+	case *ast.IndexExpr:
+		// An instantiation of a generic option function.
+		return optionFunc(info, x.X)
+	}
+	return fn
+}
+
+// assignedOnce returns the values of the variables of the package that are
+// initialized when declared and never assigned nor addressed afterwards.
+func assignedOnce(pass *analysis.Pass) map[*types.Var]ast.Expr {
+	values := map[*types.Var]ast.Expr{}
+	reassigned := map[*types.Var]bool{}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for ii, lhs := range n.Lhs {
+					id, ok := ast.Unparen(lhs).(*ast.Ident)
+					if !ok {
+						continue
+					}
+					if v, ok := pass.TypesInfo.Defs[id].(*types.Var); ok && len(n.Lhs) == len(n.Rhs) {
+						values[v] = n.Rhs[ii]
+					} else if v, ok := pass.TypesInfo.ObjectOf(id).(*types.Var); ok {
+						reassigned[v] = true
+					}
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) != len(n.Values) {
+					return true
+				}
+				for ii, id := range n.Names {
+					if v, ok := pass.TypesInfo.Defs[id].(*types.Var); ok {
+						values[v] = n.Values[ii]
+					}
+				}
+			case *ast.UnaryExpr:
+				if id, ok := ast.Unparen(n.X).(*ast.Ident); ok && n.Op == token.AND {
+					if v, ok := pass.TypesInfo.Uses[id].(*types.Var); ok {
+						reassigned[v] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	for v := range reassigned {
+		delete(values, v)
+	}
+	return values
+}
+
+// optionNames returns the names of the alternative options, without their
+// package paths, for use in diagnostics.
+func optionNames(alts []string) string {
+	var names []string
+	for _, name := range alts {
+		names = append(names, name[strings.LastIndex(name, ".")+1:])
+	}
+	return strings.Join(names, " or ")
+}
@@ -0,0 +1,13 @@
+package funcopts_test
+
+import (
+	"testing"
+
+	"github.com/cederstone/analysis/passes/funcopts"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestFuncopts(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, funcopts.Analyzer, "client", "app") // loads testdata/src/client and testdata/src/app.
+}
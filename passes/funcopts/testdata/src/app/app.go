@@ -0,0 +1,55 @@
+package app
+
+import (
+	"time"
+
+	"client"
+	"retry"
+)
+
+func clients(opts []client.Option) {
+	client.New("a", client.WithTimeout(time.Second), client.WithRetries(3))
+	client.New("a", client.WithDeadline(time.Now()), client.WithNoRetries)
+	client.New("a", (client.WithNoRetries), client.WithTimeout(0))
+	client.New("a", opts...)
+	client.New("a", client.WithRetries(3))        // want `call of New is missing required option WithTimeout or WithDeadline`
+	client.New("a")                               // want `call of New is missing required option WithTimeout or WithDeadline` `call of New is missing required option WithRetries or WithNoRetries`
+	client.NewPool()                              // want `call of NewPool is missing required option WithTimeout`
+	client.New("a", func(*client.Client) {}, nil) // want `call of New is missing required option WithTimeout or WithDeadline` `call of New is missing required option WithRetries or WithNoRetries`
+	f := client.New
+	f("a")
+
+	timeout, retries := client.WithTimeout(time.Second), client.WithNoRetries
+	client.New("a", timeout, retries)
+	var none client.Option = func(*client.Client) {}
+	client.New("a", timeout, none) // want `call of New is missing required option WithRetries or WithNoRetries`
+	reassigned := client.WithRetries(1)
+	reassigned = client.WithRetries(2)
+	client.New("a", timeout, reassigned) // not checked
+}
+
+func withOption(opt client.Option) {
+	client.New("a", opt) // not checked
+}
+
+type config struct {
+	Addr string
+}
+
+func withAddr(addr string, c config) {
+	// The addresses aren't options.
+	client.New(addr)                         // want `call of New is missing required option WithTimeout or WithDeadline` `call of New is missing required option WithRetries or WithNoRetries`
+	client.New(c.Addr, client.WithNoRetries) // want `call of New is missing required option WithTimeout or WithDeadline`
+	client.New(addr+c.Addr, client.WithTimeout(0), client.WithRetries(1))
+	client.Dial(addr)                                // want `call of Dial is missing required option Policy`
+	client.NewFirst(client.WithTimeout(time.Second)) // want `call of NewFirst is missing required option WithRetries or WithNoRetries`
+	client.NewFirst(client.WithDeadline(time.Now()), client.WithNoRetries)
+}
+
+func dial() {
+	client.Dial("a", retry.Policy{Attempts: 3})
+	policy := &retry.Policy{Attempts: 3}
+	client.Dial("a", policy)
+	client.Dial("a", 3) // want `call of Dial is missing required option Policy`
+	client.Dial("a")    // want `call of Dial is missing required option Policy`
+}
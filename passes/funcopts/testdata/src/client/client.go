@@ -0,0 +1,65 @@
+package client
+
+import (
+	"time"
+
+	"retry"
+)
+
+type Client struct {
+	timeout  time.Duration
+	deadline time.Time
+	retries  int
+}
+
+type Option func(*Client)
+
+func WithTimeout(d time.Duration) Option { return func(c *Client) { c.timeout = d } }
+
+func WithDeadline(t time.Time) Option { return func(c *Client) { c.deadline = t } }
+
+func WithRetries(n int) Option { return func(c *Client) { c.retries = n } }
+
+func WithNoRetries(c *Client) { c.retries = 0 }
+
+// New returns a client.
+//
+//cederstone:requires WithTimeout|WithDeadline WithRetries|WithNoRetries
+func New(addr string, opts ...Option) *Client { // want New:`requires\(client.WithTimeout\|client.WithDeadline client.WithRetries\|client.WithNoRetries\)`
+	c := new(Client)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+/* want `required option WithBackoff is not a function or type of package client` */ //cederstone:requires WithTimeout WithBackoff
+func NewPool(opts ...Option) []*Client {                                             // want NewPool:`requires\(client.WithTimeout\)`
+	return []*Client{New("a", opts...)}
+}
+
+// Dial dials addr with the retry policy among opts.
+//
+//cederstone:requires retry.Policy
+func Dial(addr string, opts ...interface{}) { // want Dial:`requires\(retry.Policy\)`
+	for _, opt := range opts {
+		if p, ok := opt.(retry.Policy); ok {
+			_ = p.Attempts
+		}
+	}
+}
+
+// NewFirst returns a client with at least one option.
+//
+//cederstone:requires WithTimeout|WithDeadline WithRetries|WithNoRetries
+func NewFirst(first Option, more ...Option) *Client { // want NewFirst:`requires\(client.WithTimeout\|client.WithDeadline client.WithRetries\|client.WithNoRetries\)`
+	return New("localhost", append([]Option{first}, more...)...)
+}
+
+/* want `//cederstone:requires directive lists no options` */ //cederstone:requires
+func NewNothing() {
+}
+
+func defaults() *Client {
+	return New("localhost", WithTimeout(time.Second)) // want `call of New is missing required option WithRetries or WithNoRetries`
+}
@@ -0,0 +1,5 @@
+package retry
+
+type Policy struct {
+	Attempts int
+}
@@ -0,0 +1,168 @@
+package keyedlit
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A fieldsFact lists the annotated fields of a named struct type. Fields are
+// annotated by an option of their 'cederstone' struct tag, like
+// 'cederstone:"optional"', or by the equivalent directive in their doc or
+// line comment, like '//cederstone:optional'. The fact is exported for the
+// struct types of every package so that literals of their types are checked
+// according to the annotations in all downstream packages.
+type fieldsFact struct {
+	// Optional are the fields that never need to be specified.
+	Optional []string
+	// Required are the fields that must always be specified.
+	Required []string
+}
+
+func (*fieldsFact) AFact() {}
+
+func (f *fieldsFact) String() string {
+	var parts []string
+	if len(f.Optional) != 0 {
+		parts = append(parts, "optional("+strings.Join(f.Optional, ", ")+")")
+	}
+	if len(f.Required) != 0 {
+		parts = append(parts, "required("+strings.Join(f.Required, ", ")+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *fieldsFact) empty() bool {
+	return len(f.Optional) == 0 && len(f.Required) == 0
+}
+
+func (f *fieldsFact) optional(name string) bool {
+	return f != nil && contains(f.Optional, name)
+}
+
+func (f *fieldsFact) required(name string) bool {
+	return f != nil && contains(f.Required, name)
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+// exportFieldsFacts exports a fieldsFact for each struct type declared in the
+// package with annotated fields.
+func exportFieldsFacts(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok || spec.Assign.IsValid() {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			obj := pass.TypesInfo.Defs[spec.Name]
+			if obj == nil {
+				return true
+			}
+			if fact := fieldsFactOf(st); !fact.empty() {
+				pass.ExportObjectFact(obj, fact)
+			}
+			return true
+		})
+	}
+}
+
+// fieldsFactOf returns the annotations of the fields of st.
+func fieldsFactOf(st *ast.StructType) *fieldsFact {
+	fact := new(fieldsFact)
+	for _, field := range st.Fields.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		if len(field.Names) == 0 {
+			// The name of an embedded field is that of its type.
+			if name, ok := embeddedName(field.Type); ok {
+				names = append(names, name)
+			}
+		}
+		if hasAnnotation(field, "optional") {
+			fact.Optional = append(fact.Optional, names...)
+		}
+		if hasAnnotation(field, "required") {
+			fact.Required = append(fact.Required, names...)
+		}
+	}
+	return fact
+}
+
+// hasAnnotation reports whether field is annotated with the option opt,
+// either by its struct tag or by a directive.
+func hasAnnotation(field *ast.Field, opt string) bool {
+	if field.Tag != nil {
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		if value, ok := reflect.StructTag(tag).Lookup("cederstone"); ok {
+			for _, o := range strings.Split(value, ",") {
+				if o == opt {
+					return true
+				}
+			}
+		}
+	}
+	directive := "//cederstone:" + opt
+	for _, cg := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if cg == nil {
+			continue
+		}
+		for _, c := range cg.List {
+			if c.Text == directive || strings.HasPrefix(c.Text, directive+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// embeddedName returns the name of the embedded field of type expr.
+func embeddedName(expr ast.Expr) (string, bool) {
+	for {
+		switch x := expr.(type) {
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.SelectorExpr:
+			return x.Sel.Name, true
+		case *ast.Ident:
+			return x.Name, true
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.IndexListExpr:
+			expr = x.X
+		default:
+			return "", false
+		}
+	}
+}
+
+// annotationsOf returns the annotations of the fields of t, which may be
+// declared in another package.
+func annotationsOf(pass *analysis.Pass, t types.Type) *fieldsFact {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil
+	}
+	fact := new(fieldsFact)
+	if !pass.ImportObjectFact(named.Origin().Obj(), fact) {
+		return nil
+	}
+	return fact
+}
@@ -0,0 +1,257 @@
+package keyedlit
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cederstone/analysis/internal/pattern"
+	"github.com/cederstone/analysis/passes/keyedlit/lockfile"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the contents of the file named by the 'config' flag. It is
+// written in YAML or JSON:
+//
+//	rules:
+//	  # Fields of a particular struct type.
+//	  - type: net/http.Server
+//	    fields: [ReadHeaderTimeout, IdleTimeout]
+//	  # Fields of any struct type whose name contains a match of the name
+//	  # regular expression and whose type matches the fieldType regular
+//	  # expression.
+//	  - name: Deadline
+//	    fieldType: time.Duration
+//	strict:
+//	  # Strict mode for literals of the config types of our own packages.
+//	  - packages: [github.com/ourorg/...]
+//	    types: [github.com/ourorg/.../config]
+//	allow:
+//	  # Fields that may always be omitted.
+//	  - type: net/http.Client
+//	    fields: [Jar, CheckRedirect]
+//
+// The rules of a config are checked in addition to the default rules.
+// Packages and types are matched by patterns in which '...' matches any
+// string, as in the go command. A type matches a pattern if its package path
+// or its package path and name do.
+type config struct {
+	Rules  []*rule        `yaml:"rules"`
+	Strict []*strictScope `yaml:"strict"`
+	Allow  []*allowance   `yaml:"allow"`
+}
+
+// A strictScope enables strict mode for literals of some types in some
+// packages. Empty lists match everything.
+type strictScope struct {
+	// Packages are patterns matching the packages containing literals.
+	Packages []string `yaml:"packages"`
+	// Types are patterns matching the types of literals.
+	Types []string `yaml:"types"`
+}
+
+// An allowance lists fields that never need to be specified.
+type allowance struct {
+	// Type is a pattern matching the struct types whose fields are
+	// allowed to be omitted.
+	Type   string   `yaml:"type"`
+	Fields []string `yaml:"fields"`
+}
+
+// strict reports whether literals of the struct type typeName in the package
+// pkgPath are checked in strict mode. External test packages are in the scope
+// of the package they test.
+func (cfg *config) strict(pkgPath, typeName string) bool {
+	pkgPath = strings.TrimSuffix(pkgPath, "_test")
+	for _, scope := range cfg.Strict {
+		if matchAny(scope.Packages, pkgPath) && matchAnyType(scope.Types, typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether field of the struct type typeName may be omitted.
+func (cfg *config) allowed(typeName string, field *types.Var) bool {
+	for _, a := range cfg.Allow {
+		if !matchAnyType([]string{a.Type}, typeName) {
+			continue
+		}
+		for _, name := range a.Fields {
+			if name == field.Name() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny reports whether s matches any of patterns, or patterns is empty.
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if pattern.Match(p, s) {
+			return true
+		}
+	}
+	return len(patterns) == 0
+}
+
+// matchAnyType reports whether the qualified name of a type, or its package
+// path, matches any of patterns, or patterns is empty. Unnamed types only
+// match empty lists of patterns.
+func matchAnyType(patterns []string, typeName string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	dot := strings.LastIndex(typeName, ".")
+	if dot < 0 {
+		return false
+	}
+	return matchAny(patterns, typeName) || matchAny(patterns, typeName[:dot])
+}
+
+// A rule selects fields of keyed literals that must be specified. A field is
+// selected if it matches all of the rule's non-empty predicates.
+type rule struct {
+	// Type is the struct type whose literals are checked, written as its
+	// package path and name, e.g. 'net/http.Client'.
+	Type string `yaml:"type"`
+	// Fields are the names of the fields that must be specified.
+	Fields []string `yaml:"fields"`
+	// Name is a regular expression that must match part of the name of
+	// the field.
+	Name string `yaml:"name"`
+	// FieldType is a regular expression that must match the entire type
+	// of the field, e.g. 'time.Duration' or '\*crypto/tls.Config'.
+	FieldType string `yaml:"fieldType"`
+
+	name      *regexp.Regexp
+	fieldType *regexp.Regexp
+}
+
+// defaultRules select the Timeout and KeepAlive fields that this pass has
+// always checked.
+var defaultRules = []*rule{
+	{Name: "KeepAlive", FieldType: "time.Duration"},
+	{Name: "Timeout", FieldType: "time.Duration"},
+}
+
+func init() {
+	for _, r := range defaultRules {
+		if err := r.compile(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *rule) compile() error {
+	if r.Type == "" && len(r.Fields) == 0 && r.Name == "" && r.FieldType == "" {
+		return fmt.Errorf("rule selects all fields of all types")
+	}
+	var err error
+	if r.Name != "" {
+		r.name, err = regexp.Compile(r.Name)
+		if err != nil {
+			return fmt.Errorf("name: %v", err)
+		}
+	}
+	if r.FieldType != "" {
+		r.fieldType, err = regexp.Compile("^(?:" + r.FieldType + ")$")
+		if err != nil {
+			return fmt.Errorf("fieldType: %v", err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether field of the struct type typeName must be
+// specified according to this rule. typeName is empty for unnamed types.
+func (r *rule) matches(typeName string, field *types.Var) bool {
+	if r.Type != "" && r.Type != typeName {
+		return false
+	}
+	if len(r.Fields) != 0 {
+		listed := false
+		for _, name := range r.Fields {
+			if name == field.Name() {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			return false
+		}
+	}
+	if r.name != nil && !r.name.MatchString(field.Name()) {
+		return false
+	}
+	if r.fieldType != nil && !r.fieldType.MatchString(field.Type().String()) {
+		return false
+	}
+	return true
+}
+
+var (
+	configsMu sync.Mutex
+	configs   = map[string]*config{}
+)
+
+// loadConfig reads and caches the config file at path. An empty path yields
+// an empty config.
+func loadConfig(path string) (*config, error) {
+	configsMu.Lock()
+	defer configsMu.Unlock()
+	if cfg, ok := configs[path]; ok {
+		return cfg, nil
+	}
+	cfg := new(config)
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// A misspelled key, like 'feilds', would otherwise select
+		// all fields of the type.
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for ii, r := range cfg.Rules {
+			if err := r.compile(); err != nil {
+				return nil, fmt.Errorf("%s: rule %d: %v", path, ii+1, err)
+			}
+		}
+	}
+	configs[path] = cfg
+	return cfg, nil
+}
+
+var (
+	lockfilesMu sync.Mutex
+	lockfiles   = map[string]lockfile.Lockfile{}
+)
+
+// loadLockfile reads and caches the lockfile at path. An empty path yields a
+// nil lockfile.
+func loadLockfile(path string) (lockfile.Lockfile, error) {
+	if path == "" {
+		return nil, nil
+	}
+	lockfilesMu.Lock()
+	defer lockfilesMu.Unlock()
+	if lock, ok := lockfiles[path]; ok {
+		return lock, nil
+	}
+	lock, err := lockfile.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	lockfiles[path] = lock
+	return lock, nil
+}
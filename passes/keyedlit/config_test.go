@@ -0,0 +1,18 @@
+package keyedlit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	path := filepath.Join(analysistest.TestData(), "misspelled.yaml")
+	_, err := loadConfig(path)
+	want := "field feilds not found"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("loadConfig(%q) = %v, want an error containing %q", path, err, want)
+	}
+}
@@ -0,0 +1,198 @@
+package keyedlit
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// fixMessage is shared by all fixes so that drivers applying fixes by
+// message apply all of them together.
+const fixMessage = "Specify field with its zero value"
+
+// specifyFix returns a fix that adds field to lit with the zero value of its
+// type, like
+//
+//	Timeout: 0, // TODO: choose Timeout
+//
+// Packages that the zero value refers to are imported if the file containing
+// lit doesn't import them yet. No fix is returned if they can't be, e.g.
+// because their name refers to something else at lit.
+func specifyFix(pass *analysis.Pass, lit *ast.CompositeLit, field *types.Var) (analysis.SuggestedFix, bool) {
+	file := enclosingFile(pass, lit.Pos())
+	if file == nil {
+		return analysis.SuggestedFix{}, false
+	}
+	names := importNames(pass, file)
+	var missing []*types.Package
+	importable := true
+	zero, ok := zeroValue(field.Type(), func(pkg *types.Package) string {
+		if name, ok := names[pkg]; ok {
+			return name
+		}
+		name := pkg.Name()
+		if !nameFree(pass, lit.Pos(), name, names) {
+			importable = false
+		}
+		names[pkg] = name
+		missing = append(missing, pkg)
+		return name
+	})
+	if !ok || !importable {
+		return analysis.SuggestedFix{}, false
+	}
+	kv := field.Name() + ": " + zero
+	edit := analysis.TextEdit{Pos: lit.Rbrace, End: lit.Rbrace}
+	if indent, ok := rbraceIndent(pass, lit); ok {
+		// The literal spans several lines, add a line of its own
+		// just before the closing brace.
+		file := pass.Fset.File(lit.Rbrace)
+		lineStart := file.LineStart(file.Line(lit.Rbrace))
+		edit.Pos, edit.End = lineStart, lineStart
+		edit.NewText = []byte(indent + "\t" + kv + ", // TODO: choose " + field.Name() + "\n")
+	} else if len(lit.Elts) == 0 {
+		edit.NewText = []byte(kv)
+	} else {
+		edit.NewText = []byte(", " + kv)
+	}
+	edits := []analysis.TextEdit{edit}
+	for _, pkg := range missing {
+		edits = append(edits, importEdit(file, pkg))
+	}
+	return analysis.SuggestedFix{
+		Message:   fixMessage,
+		TextEdits: edits,
+	}, true
+}
+
+// rbraceIndent returns the indentation of the closing brace of lit if it is on
+// a line of its own.
+func rbraceIndent(pass *analysis.Pass, lit *ast.CompositeLit) (string, bool) {
+	file := pass.Fset.File(lit.Rbrace)
+	if file.Line(lit.Lbrace) == file.Line(lit.Rbrace) {
+		return "", false
+	}
+	content, err := pass.ReadFile(file.Name())
+	if err != nil {
+		return "", false
+	}
+	start := file.Offset(file.LineStart(file.Line(lit.Rbrace)))
+	end := file.Offset(lit.Rbrace)
+	if end > len(content) {
+		return "", false
+	}
+	indent := string(content[start:end])
+	if strings.TrimLeft(indent, " \t") != "" {
+		return "", false
+	}
+	return indent, true
+}
+
+// enclosingFile returns the file of pass containing pos.
+func enclosingFile(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, f := range pass.Files {
+		if f.Pos() <= pos && pos < f.End() {
+			return f
+		}
+	}
+	return nil
+}
+
+// importNames returns the names by which packages are referred to in file.
+// The package being analyzed has an empty name.
+func importNames(pass *analysis.Pass, file *ast.File) map[*types.Package]string {
+	names := map[*types.Package]string{pass.Pkg: ""}
+	for _, spec := range file.Imports {
+		pkgName := pass.TypesInfo.PkgNameOf(spec)
+		if pkgName == nil {
+			continue
+		}
+		name := pkgName.Name()
+		if name == "_" {
+			continue
+		}
+		if name == "." {
+			name = ""
+		}
+		names[pkgName.Imported()] = name
+	}
+	return names
+}
+
+// nameFree reports whether a package imported as name could be referred to at
+// pos, as name neither refers to anything else there nor is the name of
+// another package in names.
+func nameFree(pass *analysis.Pass, pos token.Pos, name string, names map[*types.Package]string) bool {
+	for _, other := range names {
+		if other == name {
+			return false
+		}
+	}
+	scope := pass.Pkg.Scope().Innermost(pos)
+	if scope == nil {
+		scope = pass.Pkg.Scope()
+	}
+	_, obj := scope.LookupParent(name, pos)
+	return obj == nil
+}
+
+// importEdit returns an edit importing pkg in file. The import is added in
+// order to the last parenthesized import declaration, or else in a
+// declaration of its own.
+func importEdit(file *ast.File, pkg *types.Package) analysis.TextEdit {
+	spec := strconv.Quote(pkg.Path())
+	if path.Base(pkg.Path()) != pkg.Name() {
+		spec = pkg.Name() + " " + spec
+	}
+	var last *ast.GenDecl
+	for _, decl := range file.Decls {
+		if gendecl, ok := decl.(*ast.GenDecl); ok && gendecl.Tok == token.IMPORT {
+			last = gendecl
+		}
+	}
+	switch {
+	case last == nil:
+		return analysis.TextEdit{Pos: file.Name.End(), End: file.Name.End(), NewText: []byte("\n\nimport " + spec)}
+	case !last.Lparen.IsValid() || len(last.Specs) == 0:
+		return analysis.TextEdit{Pos: last.End(), End: last.End(), NewText: []byte("\n\nimport " + spec)}
+	}
+	for _, s := range last.Specs {
+		imp := s.(*ast.ImportSpec)
+		if imp.Path.Value > strconv.Quote(pkg.Path()) {
+			return analysis.TextEdit{Pos: imp.Pos(), End: imp.Pos(), NewText: []byte(spec + "\n\t")}
+		}
+	}
+	end := last.Specs[len(last.Specs)-1].End()
+	return analysis.TextEdit{Pos: end, End: end, NewText: []byte("\n\t" + spec)}
+}
+
+// zeroValue returns an expression for the zero value of t, referring to
+// packages by the names that qualifier returns.
+func zeroValue(t types.Type, qualifier types.Qualifier) (string, bool) {
+	if _, ok := t.(*types.TypeParam); ok {
+		return "", false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, qualifier) + "{}", true
+	}
+	return "", false
+}
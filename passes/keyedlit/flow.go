@@ -0,0 +1,222 @@
+package keyedlit
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/cfg"
+)
+
+// assignedAfter returns the names of the fields of the value created by expr
+// that are assigned on all paths through the enclosing function before the
+// value is otherwise used. This accepts code like
+//
+//	c := &http.Client{Transport: t}
+//	c.Timeout = 10 * time.Second
+//
+// The value must be stored in a local variable by the statement containing
+// expr. Any reference to the variable other than the assignment of one of its
+// fields ends the search, as does the end of the function. stack holds the
+// ancestors of expr, ending with expr itself.
+func assignedAfter(pass *analysis.Pass, expr ast.Expr, stack []ast.Node) map[string]bool {
+	g := enclosingCFG(stack)
+	if g == nil {
+		return nil
+	}
+	for _, b := range g.Blocks {
+		for ii, n := range b.Nodes {
+			if n.Pos() > expr.Pos() || expr.End() > n.End() {
+				continue
+			}
+			v := storedIn(pass.TypesInfo, n, expr)
+			if v == nil {
+				return nil
+			}
+			f := &flow{info: pass.TypesInfo, v: v, blocks: map[*cfg.Block]fieldSet{}}
+			return f.from(b, ii+1).fields
+		}
+	}
+	return nil
+}
+
+// enclosingCFG returns the control flow graph of the innermost function on
+// the stack.
+//
+// The graph is built here rather than taken from the ctrlflow pass, which
+// doesn't run on packages with type errors. As a result, calls to functions
+// that never return, like log.Fatal, are assumed to return, which can only
+// make the analysis more conservative.
+func enclosingCFG(stack []ast.Node) *cfg.CFG {
+	mayReturn := func(*ast.CallExpr) bool { return true }
+	for ii := len(stack) - 1; ii >= 0; ii-- {
+		switch fn := stack[ii].(type) {
+		case *ast.FuncDecl:
+			if fn.Body == nil {
+				return nil
+			}
+			return cfg.New(fn.Body, mayReturn)
+		case *ast.FuncLit:
+			return cfg.New(fn.Body, mayReturn)
+		}
+	}
+	return nil
+}
+
+// storedIn returns the local variable that the statement n stores the value
+// of expr or its address in, or nil if it stores it elsewhere. expr may also
+// be the name of a variable declared without a value by n.
+func storedIn(info *types.Info, n ast.Node, expr ast.Expr) *types.Var {
+	var lhs, rhs []ast.Expr
+	switch stmt := n.(type) {
+	case *ast.AssignStmt:
+		lhs, rhs = stmt.Lhs, stmt.Rhs
+	case *ast.ValueSpec:
+		for _, name := range stmt.Names {
+			lhs = append(lhs, name)
+		}
+		rhs = stmt.Values
+		if len(rhs) == 0 {
+			// The variables are declared with their zero
+			// value.
+			rhs = lhs
+		}
+	default:
+		return nil
+	}
+	if len(lhs) != len(rhs) {
+		return nil
+	}
+	for ii, value := range rhs {
+		value = ast.Unparen(value)
+		if unary, ok := value.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			value = ast.Unparen(unary.X)
+		}
+		if value != expr {
+			continue
+		}
+		id, ok := lhs[ii].(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		v, ok := info.ObjectOf(id).(*types.Var)
+		if !ok || v.IsField() || v.Parent() == v.Pkg().Scope() {
+			return nil
+		}
+		return v
+	}
+	return nil
+}
+
+// A fieldSet is a set of field names. The set of all fields is used for paths
+// that loop back to a block that is still being analyzed, so that they don't
+// constrain the result.
+type fieldSet struct {
+	all    bool
+	fields map[string]bool
+}
+
+func (s fieldSet) intersect(t fieldSet) fieldSet {
+	if s.all {
+		return t
+	}
+	if t.all {
+		return s
+	}
+	r := fieldSet{fields: map[string]bool{}}
+	for name := range s.fields {
+		if t.fields[name] {
+			r.fields[name] = true
+		}
+	}
+	return r
+}
+
+type flow struct {
+	info *types.Info
+	v    *types.Var
+	// blocks holds the fields assigned from the start of each analyzed
+	// block.
+	blocks map[*cfg.Block]fieldSet
+}
+
+// from returns the fields of v assigned on all paths starting at the node
+// with the given index in b before v is used.
+func (f *flow) from(b *cfg.Block, index int) fieldSet {
+	if index == 0 {
+		if s, ok := f.blocks[b]; ok {
+			return s
+		}
+		f.blocks[b] = fieldSet{all: true}
+	}
+	assigned := map[string]bool{}
+	result := func() fieldSet {
+		for _, n := range b.Nodes[index:] {
+			name, ok := f.fieldAssignment(n)
+			if ok {
+				assigned[name] = true
+				continue
+			}
+			if f.uses(n) {
+				return fieldSet{}
+			}
+		}
+		if len(b.Succs) == 0 {
+			return fieldSet{}
+		}
+		s := fieldSet{all: true}
+		for _, succ := range b.Succs {
+			s = s.intersect(f.from(succ, 0))
+		}
+		return s
+	}()
+	if !result.all {
+		// Copy the fields as they may be shared with a successor.
+		fields := map[string]bool{}
+		for name := range result.fields {
+			fields[name] = true
+		}
+		for name := range assigned {
+			fields[name] = true
+		}
+		result.fields = fields
+	}
+	if index == 0 {
+		f.blocks[b] = result
+	}
+	return result
+}
+
+// fieldAssignment returns the name of the field of v assigned by n, if n is
+// an assignment like 'v.Field = expr' that doesn't otherwise use v.
+func (f *flow) fieldAssignment(n ast.Node) (string, bool) {
+	assign, ok := n.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return "", false
+	}
+	sel, ok := assign.Lhs[0].(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok || f.info.ObjectOf(id) != f.v {
+		return "", false
+	}
+	if f.uses(assign.Rhs[0]) {
+		return "", false
+	}
+	return sel.Sel.Name, true
+}
+
+// uses reports whether n refers to v.
+func (f *flow) uses(n ast.Node) bool {
+	used := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && f.info.Uses[id] == f.v {
+			used = true
+		}
+		return !used
+	})
+	return used
+}
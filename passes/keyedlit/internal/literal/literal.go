@@ -0,0 +1,34 @@
+// Package literal holds the helpers shared by the keyedlit pass and its
+// lockfile.
+package literal
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// QualifiedName returns the package path and name of the named type t, e.g.
+// 'net/http.Client', by which rules and lockfiles refer to it. It returns the
+// empty string for other types.
+func QualifiedName(t types.Type) string {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
+
+// IsKeyed reports whether lit is a keyed composite literal.
+func IsKeyed(lit *ast.CompositeLit) bool {
+	isKeyedLiteral := false
+	for _, e := range lit.Elts {
+		_, ok := e.(*ast.KeyValueExpr)
+		if !ok {
+			// If any of the elements are not keyed, none
+			// of them are.
+			return false
+		}
+		isKeyedLiteral = true
+	}
+	return isKeyedLiteral
+}
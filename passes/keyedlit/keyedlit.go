@@ -5,6 +5,47 @@
 // 'KeepAlive' is explicitly set instead of relying on default values. If the
 // 'strict' flag is set, all exported fields must be specified.
 //
+// Additional fields that must be specified are selected by the rules of the
+// file named by the 'config' flag. Rules select fields by the struct type
+// they belong to, by name and by type. The config can also enable strict
+// mode for particular packages and types, and allow fields to be omitted.
+//
+// Fields annotated as optional never need to be specified, even in strict
+// mode, and fields annotated as required must always be specified. Struct
+// authors annotate fields with a struct tag like 'cederstone:"optional"' or a
+// comment like '//cederstone:required', and the annotations are exported as
+// facts so that they apply in all packages.
+//
+// If the 'lockfile' flag names a lockfile written by 'cedercheck snapshot',
+// keyed literals whose types gained exported fields since the snapshot are
+// reported with the new fields.
+//
+// Test files are skipped unless the 'tests' flag is set.
+//
+// A field that isn't specified in a literal is accepted if the literal is
+// stored in a local variable and the field is assigned on all paths through
+// the function before the variable is otherwise used.
+//
+// Struct literals nested as field values are checked too, as are the
+// promoted fields of unspecified embedded structs. Their fields are reported
+// by their path from the outermost literal, e.g.
+// 'Transport.TLSClientConfig.MinVersion'.
+//
+// Diagnostics come with a suggested fix that specifies the field with the
+// zero value of its type and a TODO comment, so that the pass can be adopted
+// by applying all fixes and then choosing values at leisure.
+//
+// If the 'meaningful' flag is set, Timeout and KeepAlive durations set to a
+// constant must be meaningful: a zero duration, which usually means infinity,
+// must be justified by a '//cederstone:infinite' comment following the field
+// on its line or on a line of its own above it, and negative durations or
+// ones longer than the 'max-duration' flag are reported.
+//
+// If the 'zero' flag is set, zero values of struct types whose fields must be
+// specified are reported too. These are created by declarations like
+// 'var c http.Client', by 'new(http.Client)' and by literals without any
+// fields like '&http.Client{}'.
+//
 // This pass guards against users trusting the default timeout value of 0 which
 // usually indicates an infinite value. Timeouts and KeepAlives should never be
 // set by default and certainly never default to infinity. This pass helps
@@ -20,10 +61,15 @@ package keyedlit
 
 import (
 	"flag"
+	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
+	"time"
 
+	"github.com/cederstone/analysis/internal/ignore"
+	"github.com/cederstone/analysis/passes/keyedlit/internal/literal"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -34,111 +80,325 @@ const Doc = `check for important, unspecified fields in keyed literals.
 This checker reports unset Timeout / KeepAlive fields in keyed literals. These
 are often overlooked (e.g., when preparing a net/http.Client) and lead to
 production issues due to the default value of infinity for Timeout and no
-KeepAlives.`
+KeepAlives. Further fields that must be specified can be selected by the rules
+of a config file.`
 
 // flags
 var (
 	strictF bool
+	configF string
+	zeroF   bool
+	testsF  bool
+
+	lockfileF string
+
+	meaningfulF  bool
+	maxDurationF time.Duration
 )
 
 var Analyzer = &analysis.Analyzer{
 	Name:             "keyedlit",
 	Doc:              Doc,
 	Requires:         []*analysis.Analyzer{inspect.Analyzer},
-	Run:              run,
+	Run:              ignore.RunExamining(run, examines),
 	RunDespiteErrors: true,
+	FactTypes:        []analysis.Fact{new(fieldsFact)},
 	Flags: func() flag.FlagSet {
 		fs := flag.NewFlagSet("keyedlit", flag.ExitOnError)
 		fs.BoolVar(&strictF, "strict", false, "must specify all exported fields in keyed literals")
+		fs.StringVar(&configF, "config", "", "YAML or JSON `file` of rules selecting fields that must be specified")
+		fs.BoolVar(&testsF, "tests", false, "check test files too")
+		fs.StringVar(&lockfileF, "lockfile", "", "lockfile written by 'cedercheck snapshot'; report types of keyed literals that gained fields since")
+		fs.BoolVar(&zeroF, "zero", false, "check zero values of struct types whose fields must be specified")
+		fs.BoolVar(&meaningfulF, "meaningful", false, "report Timeout and KeepAlive durations that are zero without justification, negative or too long")
+		fs.DurationVar(&maxDurationF, "max-duration", defaultMaxDuration, "longest Timeout or KeepAlive duration accepted in meaningful mode")
 		return *fs
 	}(),
 }
 
+// examines reports whether the pass examines file, which it doesn't for test
+// files unless the 'tests' flag is set.
+func examines(pass *analysis.Pass, file *ast.File) bool {
+	return testsF || !isTestFile(pass, file)
+}
+
+// isTestFile reports whether n is in a test file.
+func isTestFile(pass *analysis.Pass, n ast.Node) bool {
+	return strings.HasSuffix(pass.Fset.Position(n.Pos()).Filename, "_test.go")
+}
+
 func run(pass *analysis.Pass) (interface{}, error) {
 	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	cfg, err := loadConfig(configF)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := loadLockfile(lockfileF)
+	if err != nil {
+		return nil, err
+	}
+	exportFieldsFacts(pass)
 
 	nodeFilter := []ast.Node{
 		(*ast.CompositeLit)(nil),
 	}
-	inspect.Preorder(nodeFilter, func(n ast.Node) {
+	if zeroF {
+		nodeFilter = append(nodeFilter, (*ast.ValueSpec)(nil), (*ast.CallExpr)(nil))
+	}
+	var infinite map[*token.File]map[int]token.Pos
+	if meaningfulF {
+		infinite = infiniteLines(pass)
+	}
+	inspect.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
 		// Skip tests.
-		if strings.HasSuffix(pass.Fset.Position(n.Pos()).Filename, "_test.go") {
-			return
+		if !testsF && isTestFile(pass, n) {
+			return true
 		}
-		lit := n.(*ast.CompositeLit)
-		// Get the type being created.
-		t := pass.TypesInfo.TypeOf(lit).Underlying()
-		// Ignore it unless the type is that of a struct.
-		s, ok := t.(*types.Struct)
-		if !ok {
-			return
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			checkLiteral(pass, cfg, n, stack)
+			if lock != nil {
+				checkNewFields(pass, lock, n)
+			}
+			if meaningfulF {
+				checkDurations(pass, cfg, n, infinite)
+			}
+		case *ast.ValueSpec:
+			checkZeroVar(pass, cfg, n, stack)
+		case *ast.CallExpr:
+			checkNew(pass, cfg, n, stack)
 		}
-		var typeName string
-		switch x := lit.Type.(type) {
-		case *ast.SelectorExpr:
-			typeName = x.Sel.String()
-		case *ast.Ident:
-			typeName = x.String()
+		return true
+	})
+
+	return nil, nil
+}
+
+// checkLiteral reports the fields of lit that must be specified but aren't.
+// stack holds the ancestors of lit, ending with lit itself.
+func checkLiteral(pass *analysis.Pass, cfg *config, lit *ast.CompositeLit, stack []ast.Node) {
+	// Get the type being created.
+	t := literalType(pass, lit)
+	// Ignore it unless the type is that of a struct.
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return
+	}
+	if checkedByParent(pass, stack) {
+		// The fields of this literal are reported relative to the
+		// literal it is nested in.
+		return
+	}
+	if len(lit.Elts) == 0 && zeroF {
+		checkZeroValue(pass, cfg, lit, t, stack)
+		return
+	}
+	// Ignore unless this is a keyed composite literal.
+	if !literal.IsKeyed(lit) {
+		return
+	}
+	// The type is named after its declaration rather than the
+	// literal, whose type may be elided, parenthesized, instantiated
+	// or spelled out.
+	checkValue(pass, cfg, lit, lit, t, typeNameOf(t), stack)
+}
+
+// literalType returns the type of the value created by lit. The type of an
+// elided literal of an element or key of pointer type is that pointer type,
+// while the literal creates the value it points to.
+func literalType(pass *analysis.Pass, lit *ast.CompositeLit) types.Type {
+	t := types.Unalias(pass.TypesInfo.TypeOf(lit))
+	if ptr, ok := t.(*types.Pointer); ok && lit.Type == nil {
+		t = types.Unalias(ptr.Elem())
+	}
+	return t
+}
+
+// checkedByParent reports whether the struct literal at the top of the stack
+// is the value of a field in an enclosing struct literal, which checks it.
+func checkedByParent(pass *analysis.Pass, stack []ast.Node) bool {
+	for ii := len(stack) - 2; ii >= 0; ii-- {
+		switch n := stack[ii].(type) {
+		case *ast.ParenExpr:
+			continue
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				continue
+			}
+			return false
+		case *ast.KeyValueExpr:
+			if ii == 0 || n.Value != stack[ii+1] {
+				return false
+			}
+			parent, ok := stack[ii-1].(*ast.CompositeLit)
+			if !ok {
+				return false
+			}
+			_, ok = literalType(pass, parent).Underlying().(*types.Struct)
+			return ok
 		default:
-			return
+			return false
+		}
+	}
+	return false
+}
+
+// A missingField is a field that must be specified but isn't.
+type missingField struct {
+	// pos is the position of the literal the field is missing from.
+	pos token.Pos
+	// path names the field relative to the outermost value, e.g.
+	// 'Transport.TLSClientConfig.MinVersion'.
+	path string
+	// selector is the name the field can be assigned by on the
+	// outermost value, if any.
+	selector string
+	// lit is the literal the field can be added to, if any.
+	lit   *ast.CompositeLit
+	field *types.Var
+}
+
+// checkValue reports the fields of the struct type t that must be specified
+// but aren't. The value of type t is created by expr, which is either the
+// literal lit or creates a zero value without a literal, in which case lit is
+// nil. stack holds the ancestors of expr, ending with expr itself.
+func checkValue(pass *analysis.Pass, cfg *config, expr ast.Expr, lit *ast.CompositeLit, t types.Type, typeName string, stack []ast.Node) {
+	missing := missingFields(pass, cfg, t, lit, expr.Pos(), "", true)
+	if len(missing) == 0 {
+		return
+	}
+	// Accept fields that are assigned right after the value is stored
+	// in a local variable.
+	assigned := assignedAfter(pass, expr, stack)
+	for _, field := range missing {
+		if field.selector != "" && assigned[field.selector] {
+			continue
+		}
+		d := analysis.Diagnostic{
+			Pos:     field.pos,
+			Message: fmt.Sprintf("unspecified field %s of %s", field.path, typeName),
+		}
+		if field.lit != nil {
+			if fix, ok := specifyFix(pass, field.lit, field.field); ok {
+				d.SuggestedFixes = append(d.SuggestedFixes, fix)
+			}
+		}
+		pass.Report(d)
+	}
+}
+
+// missingFields returns the fields of the struct type t that must be
+// specified but are not specified by lit, which is nil for a zero value
+// created without a literal.
+// Nested literals of struct type and embedded structs are checked
+// recursively. pos is the position of lit or the zero value, prefix is the
+// path to the value and assignable reports whether its fields can be
+// assigned by name on the outermost value.
+func missingFields(pass *analysis.Pass, cfg *config, t types.Type, lit *ast.CompositeLit, pos token.Pos, prefix string, assignable bool) []missingField {
+	s := t.Underlying().(*types.Struct)
+	qualifiedName := literal.QualifiedName(t)
+	annotations := annotationsOf(pass, t)
+	specified := map[string]ast.Expr{}
+	if lit != nil {
+		for _, el := range lit.Elts {
+			kve := el.(*ast.KeyValueExpr)
+			keyIdent, ok := kve.Key.(*ast.Ident)
+			if !ok {
+				// No idea what this is, skip it.
+				continue
+			}
+			specified[keyIdent.Name] = kve.Value
 		}
-		// Ignore unless this is a keyed composite literal.
-		isKeyedLiteral := false
-		for _, e := range lit.Elts {
-			_, ok := e.(*ast.KeyValueExpr)
+	}
+	// Loop through its fields, looking for ones selected by the
+	// rules.
+	var missing []missingField
+	for ii := 0; ii < s.NumFields(); ii++ {
+		field := s.Field(ii)
+		if !field.Exported() && field.Pkg() != pass.Pkg {
+			// Unexported fields of other packages can't be
+			// specified.
+			continue
+		}
+		path := prefix + field.Name()
+		if value, ok := specified[field.Name()]; ok {
+			// Check the fields of nested struct literals.
+			nested, ok := nestedLiteral(pass, value)
 			if !ok {
-				// If any of the elements are not keyed, none
-				// of them are.
-				return
+				continue
+			}
+			nt := types.Unalias(pass.TypesInfo.TypeOf(nested))
+			if (len(nested.Elts) == 0 && zeroF) || literal.IsKeyed(nested) {
+				missing = append(missing, missingFields(pass, cfg, nt, nested, nested.Pos(), path+".", false)...)
 			}
-			isKeyedLiteral = true
+			continue
 		}
-		if !isKeyedLiteral {
-			return
+		// Annotations of the struct type take precedence over the
+		// config, and required fields take precedence over optional
+		// ones.
+		if annotations.required(field.Name()) || !annotations.optional(field.Name()) && mustBeSpecified(cfg, pass.Pkg.Path(), qualifiedName, field) {
+			m := missingField{pos: pos, path: path, lit: lit, field: field}
+			if assignable {
+				m.selector = field.Name()
+			}
+			missing = append(missing, m)
+			continue
 		}
-		// Loop through its fields, looking for ones that contain the
-		// substrings 'Timeout' or 'KeepAlive'.
-		for ii := 0; ii < s.NumFields(); ii++ {
-			field := s.Field(ii)
-			if mustBeSpecified(field) {
-				fieldIsSpecified := false
-				for _, el := range lit.Elts {
-					kve := el.(*ast.KeyValueExpr)
-					keyIdent, ok := kve.Key.(*ast.Ident)
-					if !ok {
-						// No idea what this is, skip it.
-						continue
-					}
-					if keyIdent.Name == field.Name() {
-						fieldIsSpecified = true
-						break
-					}
-				}
-				if !fieldIsSpecified {
-					pass.Reportf(lit.Pos(), "unspecified field %s of %s", field.Name(), typeName)
-				}
+		// The fields of an unspecified embedded struct are promoted
+		// and keep their zero value.
+		if ft := types.Unalias(field.Type()); field.Embedded() {
+			if _, ok := ft.Underlying().(*types.Struct); ok {
+				missing = append(missing, missingFields(pass, cfg, ft, nil, pos, path+".", assignable)...)
 			}
 		}
-		return
-	})
+	}
+	return missing
+}
 
-	return nil, nil
+// nestedLiteral returns the struct literal that creates the value of expr or
+// its address.
+func nestedLiteral(pass *analysis.Pass, expr ast.Expr) (*ast.CompositeLit, bool) {
+	expr = ast.Unparen(expr)
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = ast.Unparen(unary.X)
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	_, ok = types.Unalias(pass.TypesInfo.TypeOf(lit)).Underlying().(*types.Struct)
+	return lit, ok
 }
 
-func mustBeSpecified(field *types.Var) bool {
-	if strictF {
+// mustBeSpecified reports whether field of the struct type typeName must be
+// specified in literals in the package pkgPath.
+func mustBeSpecified(cfg *config, pkgPath, typeName string, field *types.Var) bool {
+	if cfg.allowed(typeName, field) {
+		return false
+	}
+	if strictF || cfg.strict(pkgPath, typeName) {
 		// In strict mode, all exported fields must be specified.
 		return field.Exported()
 	}
-	// In non-strict mode, only KeepAlive and Timeout fields must be
-	// specified.
-	if strings.Contains(field.Name(), "KeepAlive") &&
-		field.Type().String() == "time.Duration" {
-		return true
+	// In non-strict mode, only KeepAlive and Timeout fields and those
+	// selected by the configured rules must be specified.
+	return mustBeSpecifiedByRules(cfg, typeName, field)
+}
+
+// mustBeSpecifiedByRules reports whether field is selected by the default or
+// configured rules.
+func mustBeSpecifiedByRules(cfg *config, typeName string, field *types.Var) bool {
+	for _, r := range defaultRules {
+		if r.matches(typeName, field) {
+			return true
+		}
 	}
-	if strings.Contains(field.Name(), "Timeout") &&
-		field.Type().String() == "time.Duration" {
-		return true
+	for _, r := range cfg.Rules {
+		if r.matches(typeName, field) {
+			return true
+		}
 	}
 	return false
 }
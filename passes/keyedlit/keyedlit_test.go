@@ -1,22 +1,102 @@
 package keyedlit_test
 
 import (
-        "testing"
+	"path/filepath"
+	"testing"
 
-        "golang.org/x/tools/go/analysis/analysistest"
 	"github.com/cederstone/analysis/passes/keyedlit"
+	"golang.org/x/tools/go/analysis/analysistest"
 )
 
 func TestNonStrict(t *testing.T) {
-        keyedlit.Analyzer.Flags.Set("strict", "false")
-	
-        testdata := analysistest.TestData()
-        analysistest.Run(t, testdata, keyedlit.Analyzer, "nonstrict") // loads testdata/src/a/a.go.
+	keyedlit.Analyzer.Flags.Set("strict", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "nonstrict") // loads testdata/src/a/a.go.
 }
 
 func TestStrict(t *testing.T) {
-        keyedlit.Analyzer.Flags.Set("strict", "true")
-	
-        testdata := analysistest.TestData()
-        analysistest.Run(t, testdata, keyedlit.Analyzer, "strict") // loads testdata/src/a/a.go.
+	keyedlit.Analyzer.Flags.Set("strict", "true")
+	defer keyedlit.Analyzer.Flags.Set("strict", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "strict") // loads testdata/src/a/a.go.
+}
+
+func TestConfig(t *testing.T) {
+	testdata := analysistest.TestData()
+	keyedlit.Analyzer.Flags.Set("config", filepath.Join(testdata, "config.yaml"))
+	defer keyedlit.Analyzer.Flags.Set("config", "")
+
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "config") // loads testdata/src/config/config.go.
+}
+
+func TestFlow(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "flow") // loads testdata/src/flow/flow.go.
+}
+
+func TestZero(t *testing.T) {
+	keyedlit.Analyzer.Flags.Set("zero", "true")
+	defer keyedlit.Analyzer.Flags.Set("zero", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "zero") // loads testdata/src/zero/zero.go.
+}
+
+func TestNested(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "nested") // loads testdata/src/nested/nested.go.
+}
+
+func TestFix(t *testing.T) {
+	keyedlit.Analyzer.Flags.Set("strict", "true")
+	defer keyedlit.Analyzer.Flags.Set("strict", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, keyedlit.Analyzer, "fix") // loads testdata/src/fix/fix.go.
+}
+
+func TestMeaningful(t *testing.T) {
+	keyedlit.Analyzer.Flags.Set("meaningful", "true")
+	defer keyedlit.Analyzer.Flags.Set("meaningful", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "meaningful") // loads testdata/src/meaningful/meaningful.go.
+}
+
+func TestPerPackage(t *testing.T) {
+	testdata := analysistest.TestData()
+	keyedlit.Analyzer.Flags.Set("config", filepath.Join(testdata, "perpkg.yaml"))
+	defer keyedlit.Analyzer.Flags.Set("config", "")
+	keyedlit.Analyzer.Flags.Set("tests", "true")
+	defer keyedlit.Analyzer.Flags.Set("tests", "false")
+
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "perpkg/...") // loads testdata/src/perpkg.
+}
+
+func TestOptional(t *testing.T) {
+	keyedlit.Analyzer.Flags.Set("strict", "true")
+	defer keyedlit.Analyzer.Flags.Set("strict", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "optional/...") // loads testdata/src/optional.
+}
+
+func TestRequired(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "required/...") // loads testdata/src/required.
+}
+
+func TestLockfile(t *testing.T) {
+	testdata := analysistest.TestData()
+	keyedlit.Analyzer.Flags.Set("lockfile", filepath.Join(testdata, "lockfile.json"))
+	defer keyedlit.Analyzer.Flags.Set("lockfile", "")
+
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "upgrade") // loads testdata/src/upgrade/upgrade.go.
+}
+
+func TestLiterals(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, keyedlit.Analyzer, "literals") // loads testdata/src/literals/literals.go.
 }
@@ -0,0 +1,139 @@
+// Package lockfile records the fields of the struct types used in keyed
+// literals, so that the keyedlit pass can report types that gained fields
+// since, e.g. after a dependency upgrade.
+//
+// A lockfile is written by 'cedercheck snapshot' and read by the keyedlit pass
+// when its 'lockfile' flag is set. It is a JSON object mapping the package path
+// and name of each struct type to the names of its exported fields:
+//
+//	{
+//		"net/http.Client": ["CheckRedirect", "Jar", "Timeout", "Transport"]
+//	}
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io/ioutil"
+	"sort"
+
+	"github.com/cederstone/analysis/passes/keyedlit/internal/literal"
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultPath is the name of the lockfile written by 'cedercheck snapshot'
+// unless configured otherwise.
+const DefaultPath = "keyedlit.lock"
+
+// A Lockfile maps the qualified names of struct types to the sorted names of
+// their exported fields.
+type Lockfile map[string][]string
+
+// Snapshot returns a lockfile recording the struct types of the keyed
+// literals in pkgs.
+func Snapshot(pkgs []*packages.Package) Lockfile {
+	lock := Lockfile{}
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				// Literals without any fields count as keyed, as
+				// in the keyedlit pass.
+				if lit, ok := n.(*ast.CompositeLit); ok && (len(lit.Elts) == 0 || literal.IsKeyed(lit)) {
+					lock.Record(pkg.TypesInfo.TypeOf(lit))
+				}
+				return true
+			})
+		}
+	}
+	return lock
+}
+
+// Record records the fields of t if it is a named struct type.
+func (l Lockfile) Record(t types.Type) {
+	name, fields, ok := structFields(t)
+	if ok {
+		l[name] = fields
+	}
+}
+
+// NewFields returns the exported fields of t that aren't recorded in the
+// lockfile. It returns nil if t isn't a named struct type recorded in the
+// lockfile.
+func (l Lockfile) NewFields(t types.Type) []string {
+	name, fields, ok := structFields(t)
+	if !ok {
+		return nil
+	}
+	recorded, ok := l[name]
+	if !ok {
+		return nil
+	}
+	known := map[string]bool{}
+	for _, field := range recorded {
+		known[field] = true
+	}
+	var added []string
+	for _, field := range fields {
+		if !known[field] {
+			added = append(added, field)
+		}
+	}
+	return added
+}
+
+// structFields returns the qualified name of the named struct type t and the
+// sorted names of its exported fields.
+func structFields(t types.Type) (string, []string, bool) {
+	if t == nil {
+		return "", nil, false
+	}
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		// The type of an elided literal of pointer type.
+		t = types.Unalias(ptr.Elem())
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return "", nil, false
+	}
+	named = named.Origin()
+	s, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return "", nil, false
+	}
+	fields := []string{}
+	for ii := 0; ii < s.NumFields(); ii++ {
+		if s.Field(ii).Exported() {
+			fields = append(fields, s.Field(ii).Name())
+		}
+	}
+	sort.Strings(fields)
+	return literal.QualifiedName(named), fields, true
+}
+
+// Read reads the lockfile at path.
+func Read(path string) (Lockfile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := Lockfile{}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return lock, nil
+}
+
+// Write writes the lockfile to path.
+func (l Lockfile) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,59 @@
+package lockfile_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cederstone/analysis/passes/keyedlit/lockfile"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestSnapshot(t *testing.T) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir: filepath.Join("testdata", "src", "snap"),
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		t.Fatal("failed to load testdata")
+	}
+	lock := lockfile.Snapshot(pkgs)
+	pkgPath := pkgs[0].PkgPath
+	want := lockfile.Lockfile{
+		pkgPath + ".Options": {"Name", "Retries"},
+		pkgPath + ".Generic": {"Value"},
+		pkgPath + ".Empty":   {"Flag"},
+	}
+	if !reflect.DeepEqual(lock, want) {
+		t.Errorf("Snapshot() = %v, want %v", lock, want)
+	}
+
+	// The lockfile survives a round trip through a file.
+	path := filepath.Join(t.TempDir(), lockfile.DefaultPath)
+	if err := lock.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := lockfile.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, lock) {
+		t.Errorf("Read() = %v, want %v", read, lock)
+	}
+
+	// Fields missing from the lockfile are new.
+	read[pkgPath+".Options"] = []string{"Name"}
+	options := pkgs[0].Types.Scope().Lookup("Options").Type()
+	if got, want := read.NewFields(options), []string{"Retries"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NewFields(Options) = %v, want %v", got, want)
+	}
+	delete(read, pkgPath+".Options")
+	if got := read.NewFields(options); got != nil {
+		t.Errorf("NewFields(Options) = %v for a type missing from the lockfile, want nil", got)
+	}
+}
@@ -0,0 +1,27 @@
+package snap
+
+type Options struct {
+	Name    string
+	Retries int
+	private bool
+}
+
+type Generic[T any] struct {
+	Value T
+}
+
+type Empty struct {
+	Flag bool
+}
+
+type unkeyed struct {
+	A, B int
+}
+
+var (
+	_ = Options{Name: "snap"}
+	_ = []Generic[int]{{Value: 1}}
+	_ = &Empty{}
+	_ = unkeyed{1, 2}
+	_ = struct{ X int }{X: 1}
+)
@@ -0,0 +1,125 @@
+package keyedlit
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+	"time"
+
+	"github.com/cederstone/analysis/passes/keyedlit/internal/literal"
+	"golang.org/x/tools/go/analysis"
+)
+
+// infiniteDirective justifies setting a duration field to zero.
+const infiniteDirective = "//cederstone:infinite"
+
+// defaultMaxDuration is the default value of the 'max-duration' flag.
+const defaultMaxDuration = 24 * time.Hour
+
+// infiniteLines returns the position of the infinite directive on each line
+// of each file that holds one.
+func infiniteLines(pass *analysis.Pass) map[*token.File]map[int]token.Pos {
+	lines := map[*token.File]map[int]token.Pos{}
+	for _, file := range pass.Files {
+		tf := pass.Fset.File(file.Pos())
+		for _, cg := range file.Comments {
+			for _, c := range cg.List {
+				if !strings.HasPrefix(c.Text, infiniteDirective) {
+					continue
+				}
+				if lines[tf] == nil {
+					lines[tf] = map[int]token.Pos{}
+				}
+				lines[tf][tf.Line(c.Pos())] = c.Pos()
+			}
+		}
+	}
+	return lines
+}
+
+// checkDurations reports duration fields of the keyed literal lit that must be
+// specified and are set to a constant that is zero without justification,
+// negative or longer than the 'max-duration' flag.
+func checkDurations(pass *analysis.Pass, cfg *config, lit *ast.CompositeLit, infinite map[*token.File]map[int]token.Pos) {
+	t := literalType(pass, lit)
+	s, ok := t.Underlying().(*types.Struct)
+	if !ok || !literal.IsKeyed(lit) {
+		return
+	}
+	qualifiedName := literal.QualifiedName(t)
+	for ii, el := range lit.Elts {
+		kve := el.(*ast.KeyValueExpr)
+		keyIdent, ok := kve.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		field := fieldByName(s, keyIdent.Name)
+		if field == nil || field.Type().String() != "time.Duration" {
+			continue
+		}
+		if !mustBeSpecifiedByRules(cfg, qualifiedName, field) {
+			continue
+		}
+		tv, ok := pass.TypesInfo.Types[kve.Value]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+			continue
+		}
+		d, exact := constant.Int64Val(tv.Value)
+		typeName := typeNameOf(t)
+		switch {
+		case !exact || d > int64(maxDurationF):
+			pass.Reportf(kve.Pos(), "%s of %s is longer than %s", field.Name(), typeName, maxDurationF)
+		case d < 0:
+			pass.Reportf(kve.Pos(), "negative %s of %s", field.Name(), typeName)
+		case d == 0:
+			if !justified(pass, lit, ii, infinite) {
+				pass.Reportf(kve.Pos(), "zero %s of %s must be justified by a %s comment", field.Name(), typeName, infiniteDirective)
+			}
+		}
+	}
+}
+
+// justified reports whether the zero duration of the element ii of lit is
+// justified by an infinite directive, either following the element on its
+// line or on a line of its own above it.
+func justified(pass *analysis.Pass, lit *ast.CompositeLit, ii int, infinite map[*token.File]map[int]token.Pos) bool {
+	el := lit.Elts[ii]
+	tf := pass.Fset.File(el.Pos())
+	if pos, ok := infinite[tf][tf.Line(el.End())]; ok && pos > el.End() {
+		// The directive follows the element, and no other element
+		// separates them.
+		if ii+1 == len(lit.Elts) || lit.Elts[ii+1].Pos() > pos {
+			return true
+		}
+	}
+	line := tf.Line(el.Pos())
+	if _, ok := infinite[tf][line-1]; ok {
+		// The directive is above the element and doesn't follow the
+		// previous element, which also ends before the element's
+		// line.
+		return ii == 0 || tf.Line(lit.Elts[ii-1].End()) < line-1
+	}
+	return false
+}
+
+// fieldByName returns the field of s with the given name.
+func fieldByName(s *types.Struct, name string) *types.Var {
+	for ii := 0; ii < s.NumFields(); ii++ {
+		if s.Field(ii).Name() == name {
+			return s.Field(ii)
+		}
+	}
+	return nil
+}
+
+// typeNameOf returns the name of t used in diagnostics: the name of a named
+// type without its type arguments, or the type itself spelled out with
+// package names.
+func typeNameOf(t types.Type) string {
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return types.TypeString(t, (*types.Package).Name)
+}
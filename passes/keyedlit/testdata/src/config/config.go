@@ -0,0 +1,39 @@
+package config
+
+import (
+	"net/http"
+	"time"
+)
+
+type Pool struct {
+	Size int
+	Name string
+}
+
+type Job struct {
+	Deadline     time.Time
+	DeadlineHint string
+	Name         string
+}
+
+func main() {
+	_ = &http.Server{ // want "unspecified field ReadTimeout of Server" "unspecified field ReadHeaderTimeout of Server" "unspecified field WriteTimeout of Server" "unspecified field IdleTimeout of Server"
+		Addr: ":8080",
+	}
+	_ = &http.Server{
+		Addr:              ":8080",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: time.Second,
+		WriteTimeout:      time.Second,
+		IdleTimeout:       time.Second,
+	}
+	_ = Pool{ // want "unspecified field Size of Pool"
+		Name: "pool",
+	}
+	_ = Job{ // want "unspecified field Deadline of Job"
+		Name: "job",
+	}
+	_ = Job{
+		Deadline: time.Now(),
+	}
+}
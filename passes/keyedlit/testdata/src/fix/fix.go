@@ -0,0 +1,36 @@
+package fix
+
+import (
+	"crypto/tls"
+	nethttp "net/http"
+	"time"
+)
+
+type Config struct {
+	Timeout time.Duration
+	Name    string
+	Retries int
+	Enabled bool
+	Headers nethttp.Header
+	TLS     tls.Config
+	Limits  [2]int
+	Client  *nethttp.Client
+	Inner   Inner
+}
+
+type Inner struct {
+	Size int
+}
+
+func main() {
+	_ = &nethttp.Client{ // want "unspecified field Timeout of Client"
+		Transport:     nethttp.DefaultTransport,
+		CheckRedirect: nil,
+		Jar:           nil,
+	}
+	_ = Config{ // want "unspecified field Name of Config" "unspecified field Retries of Config" "unspecified field Enabled of Config" "unspecified field Headers of Config" "unspecified field TLS of Config" "unspecified field Limits of Config" "unspecified field Client of Config" "unspecified field Inner of Config"
+		Timeout: time.Second,
+	}
+	_ = Inner{Size: 1}
+	_ = Config{Timeout: time.Second, Name: "a", Retries: 1, Enabled: true, Headers: nil, TLS: tls.Config{}, Limits: [2]int{}, Client: nil} // want "unspecified field Inner of Config"
+}
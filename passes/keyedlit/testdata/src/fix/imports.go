@@ -0,0 +1,13 @@
+package fix
+
+import (
+	"fmt"
+	"time"
+)
+
+func locked() {
+	fmt.Println(time.Second)
+	_ = Locked{ // want "unspecified field Mu of Locked" "unspecified field Buf of Locked"
+		Name: "a",
+	}
+}
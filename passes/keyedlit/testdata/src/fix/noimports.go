@@ -0,0 +1,10 @@
+package fix
+
+func buffered() {
+	_ = Buffered{Name: "a"} // want "unspecified field Buf of Buffered"
+}
+
+func shadowed(bytes int) {
+	// The fix can't refer to the bytes package.
+	_ = Buffered{Name: "a"} // want "unspecified field Buf of Buffered"
+}
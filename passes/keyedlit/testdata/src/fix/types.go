@@ -0,0 +1,17 @@
+package fix
+
+import (
+	"bytes"
+	"sync"
+)
+
+type Buffered struct {
+	Name string
+	Buf  bytes.Buffer
+}
+
+type Locked struct {
+	Name string
+	Mu   sync.Mutex
+	Buf  bytes.Buffer
+}
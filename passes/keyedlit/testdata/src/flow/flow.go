@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"net/http"
+	"time"
+)
+
+func assignedNext(t http.RoundTripper) *http.Client {
+	c := &http.Client{
+		Transport: t,
+	}
+	c.Timeout = 10 * time.Second
+	return c
+}
+
+func assignedValue(t http.RoundTripper) http.Client {
+	var c = http.Client{
+		Transport: t,
+	}
+	c.Timeout = 10 * time.Second
+	return c
+}
+
+func assignedOnAllPaths(t http.RoundTripper, slow bool) *http.Client {
+	c := &http.Client{
+		Transport: t,
+	}
+	if slow {
+		c.Timeout = time.Minute
+	} else {
+		c.Timeout = time.Second
+	}
+	return c
+}
+
+func assignedOnSomePaths(t http.RoundTripper, slow bool) *http.Client {
+	c := &http.Client{ // want "unspecified field Timeout of Client"
+		Transport: t,
+	}
+	if slow {
+		c.Timeout = time.Minute
+	}
+	return c
+}
+
+func usedBeforeAssigned(t http.RoundTripper) *http.Client {
+	c := &http.Client{ // want "unspecified field Timeout of Client"
+		Transport: t,
+	}
+	use(c)
+	c.Timeout = time.Second
+	return c
+}
+
+func assignedFromItself(t http.RoundTripper) *http.Client {
+	c := &http.Client{ // want "unspecified field Timeout of Client"
+		Transport: t,
+	}
+	c.Timeout = timeout(c)
+	return c
+}
+
+func assignedInLoop(t http.RoundTripper, n int) *http.Client {
+	c := &http.Client{ // want "unspecified field Timeout of Client"
+		Transport: t,
+	}
+	for i := 0; i < n; i++ {
+		c.Timeout += time.Second
+	}
+	return c
+}
+
+func assignedInClosure(t http.RoundTripper) func() *http.Client {
+	return func() *http.Client {
+		c := &http.Client{
+			Transport: t,
+		}
+		c.Timeout = time.Second
+		return c
+	}
+}
+
+func notStoredLocally(t http.RoundTripper) {
+	use(&http.Client{ // want "unspecified field Timeout of Client"
+		Transport: t,
+	})
+}
+
+func use(*http.Client) {}
+
+func timeout(*http.Client) time.Duration { return time.Second }
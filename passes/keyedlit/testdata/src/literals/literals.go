@@ -0,0 +1,25 @@
+package literals
+
+import (
+	"net/http"
+	"time"
+)
+
+type Pool[T any] struct {
+	Item    T
+	Timeout time.Duration
+}
+
+type Client = http.Client
+
+func literals(t http.RoundTripper) {
+	_ = []http.Client{{Transport: t}}               // want `unspecified field Timeout of Client`
+	_ = []*http.Client{{Transport: t}}              // want `unspecified field Timeout of Client`
+	_ = map[string]http.Client{"a": {Transport: t}} // want `unspecified field Timeout of Client`
+	_ = [...]http.Client{{Transport: t, Timeout: 0}}
+	_ = Client{Transport: t}        // want `unspecified field Timeout of Client`
+	_ = Pool[int]{Item: 1}          // want `unspecified field Timeout of Pool`
+	_ = []Pool[string]{{Item: "a"}} // want `unspecified field Timeout of Pool`
+	_ = struct{ Timeout time.Duration }{Timeout: 0}
+	_ = struct{ Name, Timeout time.Duration }{Name: 0} // want `unspecified field Timeout of struct{Name time.Duration; Timeout time.Duration}`
+}
@@ -0,0 +1,48 @@
+package meaningful
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const never = 0
+
+func main() {
+	_ = &http.Client{
+		Timeout: 0, // want "zero Timeout of Client must be justified by a //cederstone:infinite comment"
+	}
+	_ = &http.Client{
+		Timeout: never, // want "zero Timeout of Client must be justified by a //cederstone:infinite comment"
+	}
+	_ = &http.Client{
+		Timeout: 0, //cederstone:infinite requests are cancelled by their context
+	}
+	_ = &http.Client{
+		//cederstone:infinite requests are cancelled by their context
+		Timeout: 0,
+	}
+	_ = &net.Dialer{
+		//cederstone:infinite dials are cancelled by their context
+		Timeout: 0, KeepAlive: 0, // want "zero KeepAlive of Dialer must be justified by a //cederstone:infinite comment"
+	}
+	_ = &net.Dialer{
+		Timeout: 0, KeepAlive: 0, //cederstone:infinite keep-alives are disabled // want "zero Timeout of Dialer must be justified by a //cederstone:infinite comment"
+	}
+	_ = &net.Dialer{
+		Timeout:   0, //cederstone:infinite dials are cancelled by their context
+		KeepAlive: 0, // want "zero KeepAlive of Dialer must be justified by a //cederstone:infinite comment"
+	}
+	_ = &net.Dialer{
+		Timeout:   -time.Second, // want "negative Timeout of Dialer"
+		KeepAlive: 30 * time.Second,
+	}
+	_ = &net.Dialer{
+		Timeout:   time.Second,
+		KeepAlive: 48 * time.Hour, // want "KeepAlive of Dialer is longer than 24h0m0s"
+	}
+	timeout := time.Duration(0)
+	_ = &http.Client{
+		Timeout: timeout, // not a constant
+	}
+}
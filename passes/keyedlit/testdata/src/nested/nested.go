@@ -0,0 +1,80 @@
+package nested
+
+import (
+	"net/http"
+	"time"
+)
+
+type Limits struct {
+	Timeout time.Duration
+	Size    int
+}
+
+type Server struct {
+	Limits
+	Name string
+}
+
+type Service struct {
+	Server  Server
+	Backend *Server
+	Client  *http.Client
+}
+
+func main() {
+	_ = Server{ // want "unspecified field Limits.Timeout of Server"
+		Name: "a",
+	}
+	_ = Server{
+		Limits: Limits{ // want "unspecified field Limits.Timeout of Server"
+			Size: 1,
+		},
+	}
+	_ = Server{
+		Limits: Limits{
+			Timeout: time.Second,
+		},
+	}
+	_ = Service{
+		Server: Server{ // want "unspecified field Server.Limits.Timeout of Service"
+			Name: "a",
+		},
+		Backend: &Server{
+			Limits: Limits{ // want "unspecified field Backend.Limits.Timeout of Service"
+				Size: 1,
+			},
+		},
+		Client: &http.Client{ // want "unspecified field Client.Timeout of Service"
+			Transport: &http.Transport{ // want "unspecified field Client.Transport.TLSHandshakeTimeout of Service" "unspecified field Client.Transport.IdleConnTimeout of Service" "unspecified field Client.Transport.ResponseHeaderTimeout of Service" "unspecified field Client.Transport.ExpectContinueTimeout of Service"
+				MaxIdleConns: 1,
+			},
+		},
+	}
+	// Limits is set by the assignment of the promoted field.
+	s := &Server{
+		Name: "a",
+	}
+	s.Timeout = time.Second
+	_ = s
+	// Literals nested in elided literals of pointer type are checked by
+	// their parent.
+	_ = []*Service{{
+		Client: &http.Client{ // want "unspecified field Client.Timeout of Service"
+			Transport: http.DefaultTransport,
+		},
+	}}
+	_ = (Service){
+		Client: &http.Client{ // want "unspecified field Client.Timeout of Service"
+			Transport: http.DefaultTransport,
+		},
+	}
+	_ = struct{ Client *http.Client }{
+		Client: &http.Client{ // want "unspecified field Client.Timeout of struct{Client \\*http.Client}"
+			Transport: http.DefaultTransport,
+		},
+	}
+	// Positional literals specify all fields.
+	_ = Service{
+		Server: Server{Limits{time.Second, 1}, "a"},
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	// Test files aren't examined, so the suppression suppresses nothing
+	// without being reported.
+	//cederstone:ignore keyedlit the client is never used
+	_ = &http.Client{Transport: nil}
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"time"
+
+	"optional/lib"
+)
+
+func configs() {
+	_ = lib.Config{Name: "app", Timeout: time.Second, Extra: lib.Extra{Backoff: time.Second}}
+	_ = lib.Config{Name: "app", Extra: lib.Extra{Retries: 1}} // want `unspecified field Timeout of Config` `unspecified field Extra.Backoff of Config`
+	_ = lib.Config{Timeout: time.Second}                      // want `unspecified field Name of Config` `unspecified field Extra of Config`
+	_ = wrapper{Generic: lib.Generic[int]{Value: 1}}
+	_ = wrapper{Generic: lib.Generic[int]{Hook: nil}} // want `unspecified field Generic.Value of wrapper`
+	_ = local{Name: "app"}
+}
+
+type wrapper struct {
+	Generic lib.Generic[int]
+}
+
+type local struct { // want local:`optional\(Optional\)`
+	Name     string
+	Optional int `cederstone:"optional"`
+}
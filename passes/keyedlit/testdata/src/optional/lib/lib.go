@@ -0,0 +1,24 @@
+package lib
+
+import "time"
+
+type Config struct { // want Config:`optional\(Logger, Labels, Debug\)`
+	Name    string
+	Timeout time.Duration
+	Logger  func(string) `cederstone:"optional"`
+	// Labels are attached to everything.
+	//cederstone:optional
+	Labels map[string]string
+	Debug  bool //cederstone:optional only useful in development
+	Extra
+}
+
+type Extra struct { // want Extra:`optional\(Retries\)`
+	Retries int `json:"retries" cederstone:"optional"`
+	Backoff time.Duration
+}
+
+type Generic[T any] struct { // want Generic:`optional\(Hook\)`
+	Value T
+	Hook  func(T) `cederstone:"optional"`
+}
@@ -0,0 +1,12 @@
+package app
+
+import (
+	"perpkg/types"
+)
+
+func configs() {
+	_ = types.Config{Name: "app"} // want `unspecified field Size of Config`
+	_ = types.Config{Name: "app", Size: 1}
+	_ = types.Config{Name: "app", Size: 1, Debug: true}
+	_ = types.Other{Name: "app"}
+}
@@ -0,0 +1,11 @@
+package app
+
+import (
+	"testing"
+
+	"perpkg/types"
+)
+
+func TestConfigs(t *testing.T) {
+	_ = types.Config{Size: 1} // want `unspecified field Name of Config`
+}
@@ -0,0 +1,12 @@
+package app_test
+
+import (
+	"testing"
+
+	"perpkg/types"
+)
+
+// External tests are in the strict scope of the package they test.
+func TestExternal(t *testing.T) {
+	_ = types.Config{Name: "app"} // want `unspecified field Size of Config`
+}
@@ -0,0 +1,15 @@
+package types
+
+type Config struct {
+	Name  string
+	Size  int
+	Debug bool
+}
+
+type Other struct {
+	Name string
+	Size int
+}
+
+// Literals in this package aren't checked in strict mode.
+var DefaultConfig = Config{Name: "default"}
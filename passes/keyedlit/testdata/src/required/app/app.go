@@ -0,0 +1,23 @@
+package app
+
+import (
+	"time"
+
+	"required/lib"
+)
+
+func dial() error { return nil }
+
+func pools() {
+	_ = lib.Pool{Size: 1, Name: "app", Dial: dial, Idle: time.Second}
+	_ = &lib.Pool{Name: "app", Dial: dial, Idle: time.Second}            // want `unspecified field Size of Pool`
+	_ = wrapper{Pool: lib.Pool{Size: 1, Name: "app", Idle: time.Second}} // want `unspecified field Pool.Dial of wrapper`
+
+	p := lib.Pool{Name: "app", Dial: dial, Idle: time.Second}
+	p.Size = 2
+	_ = p
+}
+
+type wrapper struct {
+	Pool lib.Pool
+}
@@ -0,0 +1,16 @@
+package lib
+
+import "time"
+
+type Pool struct { // want Pool:`optional\(Timeout\) required\(Size, Name, Dial, limit\)`
+	Size int `cederstone:"required"`
+	//cederstone:required
+	Name    string
+	Dial    func() error  //cederstone:required
+	Timeout time.Duration `cederstone:"optional"`
+	Idle    time.Duration
+	limit   int `cederstone:"required"`
+}
+
+// Literals in the declaring package are checked too.
+var DefaultPool = Pool{Size: 1, Name: "default", Idle: time.Second} // want `unspecified field Dial of Pool` `unspecified field limit of Pool`
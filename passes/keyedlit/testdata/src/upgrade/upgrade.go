@@ -0,0 +1,22 @@
+package upgrade
+
+import "net/http"
+
+type Options struct {
+	Name    string
+	Retries int
+	Backoff int
+	private bool
+}
+
+type Unchanged struct {
+	Name string
+}
+
+func options() {
+	_ = Options{Name: "a"}      // want `Options gained fields since the lockfile was written: Backoff, Retries`
+	_ = []Options{{Retries: 1}} // want `Options gained fields since the lockfile was written: Backoff, Retries`
+	_ = &Options{}              // want `Options gained fields since the lockfile was written: Backoff, Retries`
+	_ = Unchanged{Name: "a"}
+	_ = http.Client{Timeout: 0}
+}
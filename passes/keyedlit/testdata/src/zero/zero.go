@@ -0,0 +1,45 @@
+package zero
+
+import (
+	"net/http"
+	"time"
+)
+
+var global http.Client // want "unspecified field Timeout of Client"
+
+type Point struct {
+	X, Y int
+}
+
+func main() {
+	var a http.Client // want "unspecified field Timeout of Client"
+	use(&a)
+	b := new(http.Client) // want "unspecified field Timeout of Client"
+	use(b)
+	c := &http.Client{} // want "unspecified field Timeout of Client"
+	use(c)
+	d := http.Client{} // want "unspecified field Timeout of Client"
+	use(&d)
+
+	var e http.Client
+	e.Timeout = time.Second
+	use(&e)
+	f := new(http.Client)
+	f.Timeout = time.Second
+	use(f)
+	g := &http.Client{}
+	g.Timeout = time.Second
+	use(g)
+
+	// Types without fields that must be specified.
+	var p Point
+	_ = p
+	_ = new(Point)
+	_ = Point{}
+	var s struct{ Timeout time.Duration }
+	_ = s
+	var ptr *http.Client
+	_ = ptr
+}
+
+func use(*http.Client) {}
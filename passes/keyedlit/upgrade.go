@@ -0,0 +1,25 @@
+package keyedlit
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/cederstone/analysis/passes/keyedlit/internal/literal"
+	"github.com/cederstone/analysis/passes/keyedlit/lockfile"
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkNewFields reports the keyed literal lit if its type gained exported
+// fields since the lockfile was written, e.g. because a dependency was
+// upgraded. Literals without any fields count as keyed.
+func checkNewFields(pass *analysis.Pass, lock lockfile.Lockfile, lit *ast.CompositeLit) {
+	if len(lit.Elts) != 0 && !literal.IsKeyed(lit) {
+		return
+	}
+	t := literalType(pass, lit)
+	added := lock.NewFields(t)
+	if len(added) == 0 {
+		return
+	}
+	pass.Reportf(lit.Pos(), "%s gained fields since the lockfile was written: %s", typeNameOf(t), strings.Join(added, ", "))
+}
@@ -0,0 +1,56 @@
+package keyedlit
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkZeroVar reports variables declared by spec with the zero value of a
+// struct type whose fields must be specified, as in 'var c http.Client'.
+func checkZeroVar(pass *analysis.Pass, cfg *config, spec *ast.ValueSpec, stack []ast.Node) {
+	if spec.Type == nil || len(spec.Values) != 0 {
+		return
+	}
+	for _, name := range spec.Names {
+		if name.Name == "_" {
+			continue
+		}
+		t := types.Unalias(pass.TypesInfo.TypeOf(name))
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			continue
+		}
+		checkZeroValue(pass, cfg, name, t, append(stack, name))
+	}
+}
+
+// checkNew reports calls of the builtin new with a struct type whose fields
+// must be specified, as in 'new(http.Client)'.
+func checkNew(pass *analysis.Pass, cfg *config, call *ast.CallExpr, stack []ast.Node) {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok || len(call.Args) != 1 {
+		return
+	}
+	if b, ok := pass.TypesInfo.Uses[id].(*types.Builtin); !ok || b.Name() != "new" {
+		return
+	}
+	t := types.Unalias(pass.TypesInfo.TypeOf(call.Args[0]))
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return
+	}
+	checkZeroValue(pass, cfg, call, t, stack)
+}
+
+// checkZeroValue reports the fields of the zero value of t created by expr
+// that must be specified.
+func checkZeroValue(pass *analysis.Pass, cfg *config, expr ast.Expr, t types.Type, stack []ast.Node) {
+	named, ok := t.(*types.Named)
+	if !ok {
+		// Unnamed struct types are spelled out where they are
+		// used, nothing hides their fields.
+		return
+	}
+	lit, _ := expr.(*ast.CompositeLit)
+	checkValue(pass, cfg, expr, lit, t, named.Obj().Name(), stack)
+}
@@ -0,0 +1,149 @@
+package nakedreturn
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// defaultWrappers is the default value of the 'defer-wrappers' flag.
+const defaultWrappers = "fmt.Errorf"
+
+// checkDeferred reports assignments to the named results of a function by the
+// function literals it defers, which change the returned values after the
+// return statements. The idiom of wrapping a non-nil error on its way out is
+// accepted:
+//
+//	defer func() {
+//		if err != nil {
+//			err = fmt.Errorf("frobbing %s: %w", name, err)
+//		}
+//	}()
+//
+// provided that the function wrapping the error is listed by the
+// 'defer-wrappers' flag and is passed the error.
+func checkDeferred(pass *analysis.Pass, funcType *ast.FuncType, body *ast.BlockStmt) {
+	if funcType.Results == nil || body == nil {
+		return
+	}
+	results := map[types.Object]bool{}
+	for _, field := range funcType.Results.List {
+		for _, name := range field.Names {
+			if obj := pass.TypesInfo.Defs[name]; obj != nil {
+				results[obj] = true
+			}
+		}
+	}
+	if len(results) == 0 {
+		return
+	}
+	wrappers := map[string]bool{}
+	for _, name := range strings.Split(deferWrappersF, ",") {
+		wrappers[strings.TrimSpace(name)] = true
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// The results of nested literals are checked on
+			// their own.
+			return false
+		case *ast.DeferStmt:
+			if lit, ok := ast.Unparen(n.Call.Fun).(*ast.FuncLit); ok {
+				checkDeferredLit(pass, lit, results, wrappers)
+			}
+		}
+		return true
+	})
+}
+
+// checkDeferredLit reports the assignments to results by the deferred
+// function literal lit that don't wrap an error with one of wrappers.
+func checkDeferredLit(pass *analysis.Pass, lit *ast.FuncLit, results map[types.Object]bool, wrappers map[string]bool) {
+	// result returns the named result assigned by lhs, if any.
+	result := func(lhs ast.Expr) types.Object {
+		id, ok := ast.Unparen(lhs).(*ast.Ident)
+		if !ok || !results[pass.TypesInfo.Uses[id]] {
+			return nil
+		}
+		return pass.TypesInfo.Uses[id]
+	}
+	// Find the assignments wrapping a non-nil error first.
+	wrapping := map[*ast.AssignStmt]bool{}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		ifStmt, ok := n.(*ast.IfStmt)
+		if !ok {
+			return true
+		}
+		cond, ok := ast.Unparen(ifStmt.Cond).(*ast.BinaryExpr)
+		if !ok || cond.Op != token.NEQ || !isNil(pass, cond.Y) {
+			return true
+		}
+		checked := result(cond.X)
+		if checked == nil {
+			return true
+		}
+		for _, stmt := range ifStmt.Body.List {
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+				continue
+			}
+			call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+			if !ok || result(assign.Lhs[0]) != checked {
+				continue
+			}
+			fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+			if ok && wrappers[fn.FullName()] && refersTo(pass, call.Args, checked) {
+				wrapping[assign] = true
+			}
+		}
+		return true
+	})
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		var lhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if wrapping[n] || n.Tok == token.DEFINE {
+				return true
+			}
+			lhs = n.Lhs
+		case *ast.IncDecStmt:
+			lhs = []ast.Expr{n.X}
+		default:
+			return true
+		}
+		for _, expr := range lhs {
+			if obj := result(expr); obj != nil {
+				pass.Reportf(expr.Pos(), "deferred function assigns named result %s", obj.Name())
+			}
+		}
+		return true
+	})
+}
+
+// refersTo reports whether any of exprs refers to obj.
+func refersTo(pass *analysis.Pass, exprs []ast.Expr, obj types.Object) bool {
+	found := false
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == obj {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// isNil reports whether expr is the predeclared nil.
+func isNil(pass *analysis.Pass, expr ast.Expr) bool {
+	id, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = pass.TypesInfo.Uses[id].(*types.Nil)
+	return ok
+}
@@ -17,14 +17,36 @@
 //
 // Sometimes, for very short functions, naked returns can be quite
 // elegant. However, it is my belief that they are mostly abused and not worth
-// it.
+// it. Those who disagree can set the 'max-lines' flag to only report naked
+// returns in functions spanning more lines.
 //
 // Being explicit is usually better.
+//
+// Explicit returns can still mislead when a declaration in the function body
+// shadows a named result, so that a deferred function inspecting the result
+// doesn't see the value returned. If the 'shadow' flag is set, such
+// declarations are reported.
+//
+// Named results are often introduced so that a deferred function can change
+// them, which hides control flow just like naked returns do. If the 'defer'
+// flag is set, deferred function literals assigning named results are
+// reported, except for the idiom of wrapping a non-nil error with one of the
+// functions listed by the 'defer-wrappers' flag.
+//
+// If the 'unused-names' flag is set, named results that are never used by
+// name are reported with a fix removing the names.
+//
+// Diagnostics come with a suggested fix that returns the named results
+// explicitly, so that adopting the pass is a matter of applying all fixes.
 package nakedreturn
 
 import (
+	"flag"
 	"go/ast"
+	"go/types"
+	"strings"
 
+	"github.com/cederstone/analysis/internal/ignore"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -36,17 +58,70 @@ This checker reports naked returns. These are return statements whose arguments
 are not specified that is defined in a function that has named return values in
 its signature.`
 
+// flags
+var (
+	maxLinesF int
+	shadowF   bool
+
+	deferF         bool
+	deferWrappersF string
+
+	unusedNamesF       bool
+	keepExportedNamesF bool
+)
+
 var Analyzer = &analysis.Analyzer{
 	Name:             "nakedreturn",
 	Doc:              Doc,
 	Requires:         []*analysis.Analyzer{inspect.Analyzer},
-	Run:              run,
+	Run:              ignore.Run(run),
 	RunDespiteErrors: true,
+	Flags: func() flag.FlagSet {
+		fs := flag.NewFlagSet("nakedreturn", flag.ExitOnError)
+		fs.IntVar(&maxLinesF, "max-lines", 0, "report naked returns only in functions longer than this many `lines`; 0 reports them everywhere")
+		fs.BoolVar(&shadowF, "shadow", false, "report declarations that shadow named results")
+		fs.BoolVar(&deferF, "defer", false, "report deferred function literals that assign named results")
+		fs.StringVar(&deferWrappersF, "defer-wrappers", defaultWrappers, "comma-separated `functions` that deferred function literals may wrap non-nil error results with")
+		fs.BoolVar(&unusedNamesF, "unused-names", false, "report named results that are never used by name")
+		fs.BoolVar(&keepExportedNamesF, "keep-exported-names", false, "keep the documenting names of the results of exported functions in unused-names mode")
+		return *fs
+	}(),
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
 	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 
+	if shadowF || deferF || unusedNamesF {
+		var results map[types.Object]bool
+		if shadowF {
+			results = namedResults(pass)
+		}
+		nodeFilter := []ast.Node{
+			(*ast.FuncDecl)(nil),
+			(*ast.FuncLit)(nil),
+		}
+		inspect.Preorder(nodeFilter, func(n ast.Node) {
+			var name *ast.Ident
+			var funcType *ast.FuncType
+			var body *ast.BlockStmt
+			switch fn := n.(type) {
+			case *ast.FuncDecl:
+				name, funcType, body = fn.Name, fn.Type, fn.Body
+			case *ast.FuncLit:
+				funcType, body = fn.Type, fn.Body
+			}
+			if shadowF {
+				checkShadowing(pass, results, body)
+			}
+			if deferF {
+				checkDeferred(pass, funcType, body)
+			}
+			if unusedNamesF {
+				checkUnusedNames(pass, name, funcType, body)
+			}
+		})
+	}
+
 	nodeFilter := []ast.Node{
 		(*ast.ReturnStmt)(nil),
 	}
@@ -67,6 +142,11 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			default:
 				continue traverseAncestors
 			}
+			if maxLinesF > 0 && lines(pass, ancestor) <= maxLinesF {
+				// Naked returns are accepted in short
+				// functions.
+				return true
+			}
 			if funcType.Results == nil {
 				// This function has no return values, skip.
 				return true
@@ -86,7 +166,15 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			// returns in this function.
 			if len(ret.Results) == 0 {
 				// Naked return!
-				pass.Reportf(ret.Pos(), "return values not explicitly specified")
+				d := analysis.Diagnostic{
+					Pos:     ret.Pos(),
+					End:     ret.End(),
+					Message: "return values not explicitly specified",
+				}
+				if fix, ok := explicitFix(pass, ret, funcType); ok {
+					d.SuggestedFixes = append(d.SuggestedFixes, fix)
+				}
+				pass.Report(d)
 			}
 			return true
 		}
@@ -95,3 +183,33 @@ func run(pass *analysis.Pass) (interface{}, error) {
 
 	return nil, nil
 }
+
+// lines returns the number of lines spanned by the function fn, like the
+// nakedret linter.
+func lines(pass *analysis.Pass, fn ast.Node) int {
+	return pass.Fset.Position(fn.End()).Line - pass.Fset.Position(fn.Pos()).Line
+}
+
+// explicitFix returns a fix that rewrites the naked return ret as a return of
+// the named results of funcType, like 'return n, err'. No fix is returned if a
+// result is named '_', since the rewritten return couldn't refer to it. A
+// naked return can't be reached where a result is shadowed.
+func explicitFix(pass *analysis.Pass, ret *ast.ReturnStmt, funcType *ast.FuncType) (analysis.SuggestedFix, bool) {
+	var names []string
+	for _, field := range funcType.Results.List {
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				return analysis.SuggestedFix{}, false
+			}
+			names = append(names, name.Name)
+		}
+	}
+	return analysis.SuggestedFix{
+		Message: "Return the named results explicitly",
+		TextEdits: []analysis.TextEdit{{
+			Pos:     ret.Pos(),
+			End:     ret.End(),
+			NewText: []byte("return " + strings.Join(names, ", ")),
+		}},
+	}, true
+}
@@ -11,3 +11,46 @@ func TestNakedReturn(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, nakedreturn.Analyzer, "test1") // loads testdata/src/test1/test1.go.
 }
+
+func TestFix(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, nakedreturn.Analyzer, "fix") // loads testdata/src/fix/fix.go.
+}
+
+func TestMaxLines(t *testing.T) {
+	nakedreturn.Analyzer.Flags.Set("max-lines", "3")
+	defer nakedreturn.Analyzer.Flags.Set("max-lines", "0")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, nakedreturn.Analyzer, "maxlines") // loads testdata/src/maxlines/maxlines.go.
+}
+
+func TestShadow(t *testing.T) {
+	nakedreturn.Analyzer.Flags.Set("shadow", "true")
+	defer nakedreturn.Analyzer.Flags.Set("shadow", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, nakedreturn.Analyzer, "shadow") // loads testdata/src/shadow/shadow.go.
+}
+
+func TestDefer(t *testing.T) {
+	nakedreturn.Analyzer.Flags.Set("defer", "true")
+	defer nakedreturn.Analyzer.Flags.Set("defer", "false")
+	nakedreturn.Analyzer.Flags.Set("defer-wrappers", "fmt.Errorf,deferred.wrap")
+	defer nakedreturn.Analyzer.Flags.Set("defer-wrappers", "fmt.Errorf")
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, nakedreturn.Analyzer, "deferred") // loads testdata/src/deferred/deferred.go.
+}
+
+func TestUnusedNames(t *testing.T) {
+	nakedreturn.Analyzer.Flags.Set("unused-names", "true")
+	defer nakedreturn.Analyzer.Flags.Set("unused-names", "false")
+
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, nakedreturn.Analyzer, "unused") // loads testdata/src/unused/unused.go.
+
+	nakedreturn.Analyzer.Flags.Set("keep-exported-names", "true")
+	defer nakedreturn.Analyzer.Flags.Set("keep-exported-names", "false")
+	analysistest.Run(t, testdata, nakedreturn.Analyzer, "unusedexported") // loads testdata/src/unusedexported/unusedexported.go.
+}
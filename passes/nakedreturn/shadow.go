@@ -0,0 +1,67 @@
+package nakedreturn
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkShadowing reports declarations in the body of a function that shadow
+// its named results, as in
+//
+//	func f() (err error) {
+//		defer func() { log(err) }()
+//		if err := g(); err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// where the deferred function doesn't see the error returned by g.
+// Declarations are resolved through the scopes of the package, so that each
+// is reported against the innermost result it shadows, once. The bodies of
+// nested function literals are checked on their own, and their parameters
+// and results are left alone, as callbacks commonly take an err parameter.
+func checkShadowing(pass *analysis.Pass, results map[types.Object]bool, body *ast.BlockStmt) {
+	if body == nil {
+		return
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.Ident:
+			v, ok := pass.TypesInfo.Defs[n].(*types.Var)
+			if !ok || v.IsField() || v.Parent() == nil || v.Parent().Parent() == nil {
+				return true
+			}
+			if _, shadowed := v.Parent().Parent().LookupParent(n.Name, v.Pos()); results[shadowed] {
+				pass.Reportf(n.Pos(), "declaration of %s shadows named result", n.Name)
+			}
+		}
+		return true
+	})
+}
+
+// namedResults returns the named results of the functions of the package.
+func namedResults(pass *analysis.Pass) map[types.Object]bool {
+	results := map[types.Object]bool{}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			funcType, ok := n.(*ast.FuncType)
+			if !ok || funcType.Results == nil {
+				return true
+			}
+			for _, field := range funcType.Results.List {
+				for _, name := range field.Names {
+					if obj := pass.TypesInfo.Defs[name]; obj != nil && name.Name != "_" {
+						results[obj] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	return results
+}
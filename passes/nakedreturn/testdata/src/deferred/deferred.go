@@ -0,0 +1,81 @@
+package deferred
+
+import (
+	"errors"
+	"fmt"
+)
+
+func wrap(err error) error { return err }
+
+func other(err error) error { return err }
+
+func wrapped(name string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("frobbing %s: %w", name, err)
+		}
+	}()
+	defer func() {
+		if err != nil {
+			err = wrap(err)
+		}
+	}()
+	return errors.New("failed")
+}
+
+func mutated() (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("panicked") // want "deferred function assigns named result err"
+		}
+	}()
+	defer func() {
+		n++ // want "deferred function assigns named result n"
+		if err != nil {
+			err = other(err) // want "deferred function assigns named result err"
+		}
+		if err != nil {
+			err = fmt.Errorf("replaced") // want "deferred function assigns named result err"
+		}
+		if n != 0 {
+			n = 0 // want "deferred function assigns named result n"
+		}
+		err = fmt.Errorf("always: %w", err) // want "deferred function assigns named result err"
+	}()
+	return 1, nil
+}
+
+func nested() (err error) {
+	defer func() {
+		func() {
+			err = nil // want "deferred function assigns named result err"
+		}()
+	}()
+	f := func() (err error) {
+		defer func() {
+			err = nil // want "deferred function assigns named result err"
+		}()
+		return nil
+	}
+	return f()
+}
+
+func unaffected() (err error) {
+	defer func() {
+		err := errors.New("local")
+		_ = err
+	}()
+	defer fmt.Println(err)
+	func() {
+		err = nil
+	}()
+	return err
+}
+
+func unnamed() error {
+	var err error
+	defer func() {
+		err = nil
+	}()
+	return err
+}
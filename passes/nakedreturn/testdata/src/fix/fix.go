@@ -0,0 +1,23 @@
+package fix
+
+func single() (n int) {
+	n = 1
+	return // want "return values not explicitly specified"
+}
+
+func several() (n, m int, err error) {
+	if n > 0 {
+		return // want "return values not explicitly specified"
+	}
+	return // want "return values not explicitly specified"
+}
+
+func literal() func() (string, error) {
+	return func() (s string, err error) {
+		return // want "return values not explicitly specified"
+	}
+}
+
+func blank() (_ int, err error) {
+	return // want "return values not explicitly specified"
+}
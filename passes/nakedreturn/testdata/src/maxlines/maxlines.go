@@ -0,0 +1,27 @@
+package maxlines
+
+func short() (n int) {
+	n = 1
+	return
+}
+
+func long() (n int) {
+	n = 1
+	n++
+	n++
+	return // want "return values not explicitly specified"
+}
+
+func outer() func() (n int) {
+	// Literals are measured on their own.
+	f := func() (n int) {
+		return
+	}
+	_ = f
+	return func() (n int) {
+		n = 1
+		n++
+		n++
+		return // want "return values not explicitly specified"
+	}
+}
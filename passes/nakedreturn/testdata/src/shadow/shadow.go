@@ -0,0 +1,73 @@
+package shadow
+
+import (
+	"errors"
+	"log"
+)
+
+func g() error { return errors.New("g") }
+
+func shadowed() (err error) {
+	defer func() {
+		if err != nil {
+			log.Print(err)
+		}
+	}()
+	if err := g(); err != nil { // want "declaration of err shadows named result"
+		return err
+	}
+	for _, err := range []error{nil} { // want "declaration of err shadows named result"
+		_ = err
+	}
+	var err2 error
+	return err2
+}
+
+func several() (n int, err error) {
+	n, err = 1, g()
+	{
+		n := 2 // want "declaration of n shadows named result"
+		_ = n
+	}
+	return n, err
+}
+
+func literals() (err error) {
+	// Parameters and results of literals are their own.
+	handle := func(err error) (n int) {
+		return 0
+	}
+	_ = handle
+	_ = func() (err error) {
+		err = g()
+		return err
+	}
+	_ = func() error {
+		err := g() // want "declaration of err shadows named result"
+		return err
+	}
+	_ = func() (err error) {
+		// Reported once, against the result of the literal.
+		if err := g(); err != nil { // want "declaration of err shadows named result"
+			return err
+		}
+		return nil
+	}
+	return nil
+}
+
+func unnamed() (int, error) {
+	err := g()
+	return 0, err
+}
+
+func blank() (_ int, err error) {
+	_ = 1
+	return 0, nil
+}
+
+type T struct{ err error }
+
+func (t T) field() (err error) {
+	return t.err
+}
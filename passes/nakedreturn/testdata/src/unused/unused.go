@@ -0,0 +1,47 @@
+package unused
+
+import "errors"
+
+func single() (n int) { // want "named results are never used by name"
+	return 1
+}
+
+func several() (n, m int, err error) { // want "named results are never used by name"
+	return 1, 2, nil
+}
+
+func Exported() (result map[string][]int, err error) { // want "named results are never used by name"
+	return nil, errors.New("exported")
+}
+
+func blank() (_ int, err error) { // want "named results are never used by name"
+	return 0, nil
+}
+
+func literal() func() (s string) {
+	return func() (s string) { // want "named results are never used by name"
+		return ""
+	}
+}
+
+func assigned() (n int) {
+	n = 1
+	return n
+}
+
+func naked() (n int) {
+	return // want "return values not explicitly specified"
+}
+
+func deferred() (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.New("wrapped")
+		}
+	}()
+	return nil
+}
+
+func unnamed() (int, error) {
+	return 0, nil
+}
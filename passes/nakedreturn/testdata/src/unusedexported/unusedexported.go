@@ -0,0 +1,16 @@
+package unusedexported
+
+// Divide returns the quotient and remainder of a divided by b.
+func Divide(a, b int) (quotient, remainder int) {
+	return a / b, a % b
+}
+
+func divide(a, b int) (quotient, remainder int) { // want "named results are never used by name"
+	return a / b, a % b
+}
+
+type T struct{}
+
+func (T) Divide(a, b int) (quotient, remainder int) {
+	return a / b, a % b
+}
@@ -0,0 +1,100 @@
+package nakedreturn
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkUnusedNames reports functions whose named results are never referred
+// to by name, neither in the body nor by a naked return, so that the names
+// only invite naked returns. The suggested fix removes the names.
+//
+// The names of the results of exported functions document them, they are
+// kept if the 'keep-exported-names' flag is set.
+func checkUnusedNames(pass *analysis.Pass, name *ast.Ident, funcType *ast.FuncType, body *ast.BlockStmt) {
+	if funcType.Results == nil || body == nil {
+		return
+	}
+	if name != nil && name.IsExported() && keepExportedNamesF {
+		return
+	}
+	results := map[types.Object]bool{}
+	for _, field := range funcType.Results.List {
+		for _, name := range field.Names {
+			if obj := pass.TypesInfo.Defs[name]; obj != nil {
+				results[obj] = true
+			}
+		}
+	}
+	if len(results) == 0 {
+		return
+	}
+	used := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if results[pass.TypesInfo.Uses[n]] {
+				used = true
+			}
+		case *ast.ReturnStmt:
+			if len(n.Results) == 0 {
+				used = true
+			}
+		case *ast.FuncLit:
+			// The returns of nested literals are their own, but
+			// they may refer to the results.
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && results[pass.TypesInfo.Uses[id]] {
+					used = true
+				}
+				return !used
+			})
+			return false
+		}
+		return !used
+	})
+	if used {
+		return
+	}
+	d := analysis.Diagnostic{
+		Pos:     funcType.Results.Pos(),
+		End:     funcType.Results.End(),
+		Message: "named results are never used by name",
+	}
+	if fix, ok := unnameFix(pass, funcType.Results); ok {
+		d.SuggestedFixes = append(d.SuggestedFixes, fix)
+	}
+	pass.Report(d)
+}
+
+// unnameFix returns a fix that removes the names of results, e.g. rewriting
+// '(n, m int, err error)' as '(int, int, error)'.
+func unnameFix(pass *analysis.Pass, results *ast.FieldList) (analysis.SuggestedFix, bool) {
+	var list []string
+	for _, field := range results.List {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, pass.Fset, field.Type); err != nil {
+			return analysis.SuggestedFix{}, false
+		}
+		for range field.Names {
+			list = append(list, buf.String())
+		}
+	}
+	text := "(" + strings.Join(list, ", ") + ")"
+	if len(list) == 1 {
+		text = list[0]
+	}
+	return analysis.SuggestedFix{
+		Message: "Remove the names of the results",
+		TextEdits: []analysis.TextEdit{{
+			Pos:     results.Pos(),
+			End:     results.End(),
+			NewText: []byte(text),
+		}},
+	}, true
+}
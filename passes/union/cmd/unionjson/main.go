@@ -0,0 +1,70 @@
+// unionjson generates JSON marshalling helpers for the closed tagged unions
+// of a package. It is meant to be run from a go:generate directive:
+//
+//	//go:generate unionjson -type Shape
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cederstone/analysis/passes/union/unionjson"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	fieldF  = flag.String("field", unionjson.DefaultField, "name of the JSON field holding the discriminator")
+	typesF  = flag.String("type", "", "comma-separated list of unions to generate; all unions if empty")
+	outputF = flag.String("output", "", "output file name; default <dir>/unions_json.go")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: unionjson [flags] [package]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	pattern := "."
+	if flag.NArg() > 0 {
+		pattern = flag.Arg(0)
+	}
+	if err := run(pattern); err != nil {
+		fmt.Fprintf(os.Stderr, "unionjson: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pattern string) error {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) != 1 {
+		return fmt.Errorf("%s matched %d packages, want 1", pattern, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.GoFiles) == 0 {
+		return fmt.Errorf("%s has no Go files", pkg.PkgPath)
+	}
+	opts := unionjson.Options{Field: *fieldF}
+	if *typesF != "" {
+		opts.Types = strings.Split(*typesF, ",")
+	}
+	src, err := unionjson.Generate(pkg, opts)
+	if err != nil {
+		return err
+	}
+	output := *outputF
+	if output == "" {
+		output = filepath.Join(filepath.Dir(pkg.GoFiles[0]), "unions_json.go")
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
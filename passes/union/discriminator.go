@@ -0,0 +1,117 @@
+package union
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// RegistryDirective marks the discriminator registry that unionjson generates
+// for a union. It is followed by the name of the union's interface type.
+const RegistryDirective = "//cederstone:unionjson "
+
+// checkDiscriminators reports union members that are missing from the
+// discriminator registry generated for their union. A member that was added
+// after the JSON helpers were last generated would otherwise fail to
+// unmarshal at runtime.
+func checkDiscriminators(pass *analysis.Pass, unions []*Union) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			gendecl, ok := decl.(*ast.GenDecl)
+			if !ok || gendecl.Tok != token.VAR {
+				continue
+			}
+			name, ok := registryName(gendecl.Doc)
+			if !ok {
+				continue
+			}
+			for _, u := range unions {
+				if u.Interface.Obj().Name() != name {
+					continue
+				}
+				registered := registeredMembers(pass.TypesInfo, gendecl)
+				for _, member := range u.Members {
+					had := false
+					for _, r := range registered {
+						// Registries decode into T when both T and *T are
+						// members.
+						if types.Identical(deref(member), deref(r)) {
+							had = true
+							break
+						}
+					}
+					if !had {
+						pass.Reportf(gendecl.Pos(), "union member %s of %s lacks a registered discriminator", member, name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// registryName returns the name of the union whose registry is documented by
+// doc.
+func registryName(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, RegistryDirective) {
+			return strings.TrimSpace(strings.TrimPrefix(c.Text, RegistryDirective)), true
+		}
+	}
+	return "", false
+}
+
+// registeredMembers returns the member types that a generated registry
+// decodes. Each registry entry is a function literal whose first return
+// statement returns the decoded member.
+func registeredMembers(info *types.Info, gendecl *ast.GenDecl) []types.Type {
+	var registered []types.Type
+	for _, spec := range gendecl.Specs {
+		valspec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for _, value := range valspec.Values {
+			lit, ok := value.(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			for _, el := range lit.Elts {
+				kve, ok := el.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				fn, ok := kve.Value.(*ast.FuncLit)
+				if !ok {
+					continue
+				}
+				found := false
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					if found {
+						return false
+					}
+					ret, ok := n.(*ast.ReturnStmt)
+					if !ok || len(ret.Results) == 0 {
+						return true
+					}
+					registered = append(registered, info.TypeOf(ret.Results[0]))
+					found = true
+					return false
+				})
+			}
+		}
+	}
+	return registered
+}
+
+func deref(t types.Type) types.Type {
+	if ptr, ok := t.(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return t
+}
@@ -66,3 +66,12 @@ func main() {
 		return
 	}
 }
+
+type Member1 = a.Member1
+
+func pointerToAlias(f a.Foo) {
+	switch f.(type) { // total using a pointer to a type alias
+	case *Member1, *a.Member2:
+		return
+	}
+}
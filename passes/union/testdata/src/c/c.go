@@ -0,0 +1,51 @@
+package c
+
+import "encoding/json"
+
+type Shape interface { // want Shape:"Union"
+	isShape()
+}
+
+type Circle struct{}
+
+func (*Circle) isShape() {}
+
+type Square struct{}
+
+func (Square) isShape() {}
+
+type Triangle struct{}
+
+func (*Triangle) isShape() {}
+
+// Triangle was added after the registry was generated.
+//
+//cederstone:unionjson Shape
+var shapeDiscriminators = map[string]func(data []byte) (Shape, error){ // want "union member \\*c.Triangle of Shape lacks a registered discriminator"
+	"Circle": func(data []byte) (Shape, error) {
+		v := new(Circle)
+		err := json.Unmarshal(data, v)
+		return v, err
+	},
+	"Square": func(data []byte) (Shape, error) {
+		var v Square
+		err := json.Unmarshal(data, &v)
+		return v, err
+	},
+}
+
+func describe(s Shape) {
+	// The value member Square may be matched by its pointer, which also
+	// implements Shape.
+	switch s.(type) {
+	case *Circle, *Square, *Triangle:
+	}
+
+	switch s.(type) {
+	case *Circle, Square, *Triangle:
+	}
+
+	switch s.(type) { // want "non-total type switch over union: missing c.Square"
+	case *Circle, *Triangle:
+	}
+}
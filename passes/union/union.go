@@ -4,15 +4,22 @@
 // unexported methods. This pass checks that any type switch on such an
 // interface value includes cases for all the types that satisfy the
 // interface. The unexported 'tag' function name must not take any parameters
-// nor return any values.
+// nor return any values. A member whose tag method has a value receiver may
+// be handled by a case for the member or for a pointer to it.
+//
+// The pass also checks that the discriminator registries generated by
+// unionjson are up to date, reporting members of a union that lack a
+// registered discriminator.
 
 package union
 
 import (
-	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
+	"sort"
 
+	"github.com/cederstone/analysis/internal/ignore"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -24,20 +31,26 @@ Go emulates closed tagged unions through the use of interfaces containing
 unexported methods. This pass checks that any type switch on such an interface
 value includes cases for all the types that satisfy the
 interface. The unexported 'tag' function name must not take any parameters nor
-return any values.`
+return any values.
+
+Members of a union missing from its generated JSON discriminator registry are
+also reported.`
 
 var Analyzer = &analysis.Analyzer{
 	Name:             "union",
 	Doc:              Doc,
 	Requires:         []*analysis.Analyzer{inspect.Analyzer},
-	Run:              run,
+	Run:              ignore.Run(run),
 	RunDespiteErrors: false,
 	FactTypes:        []analysis.Fact{new(union)},
 }
 
+// union is the fact that an interface is a closed tagged union. Members are
+// the strings of the member types, e.g. '*example.com/shapes.Circle', rather
+// than the types themselves so that the fact can be serialized, as when run
+// by 'go vet'.
 type union struct {
-	Interface *types.Named
-	Members   []types.Type
+	Members []string
 }
 
 func (*union) AFact() {}
@@ -47,79 +60,134 @@ func (u *union) String() string {
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
-	findTaggedUnions(pass)
+	unions := findTaggedUnions(pass)
 	checkTaggedUnions(pass)
+	checkDiscriminators(pass, unions)
 	return nil, nil
 }
 
-func findTaggedUnions(pass *analysis.Pass) {
-	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+func findTaggedUnions(pass *analysis.Pass) []*Union {
+	unions := Find(pass.Files, pass.TypesInfo)
+	for _, u := range unions {
+		fact := &union{}
+		for _, member := range u.Members {
+			fact.Members = append(fact.Members, typeString(member))
+		}
+		pass.ExportObjectFact(u.Interface.Obj(), fact)
+	}
+	return unions
+}
 
-	unions := []*union{}
-	// Find closed tagged unions. Consider using types.Type like guru instead
-	// (See https://github.com/golang/tools/blob/master/cmd/guru/implements.go)
-	nodeFilter := []ast.Node{
-		(*ast.TypeSpec)(nil),
+// typeString returns the string of t, looking through aliases of it and of
+// the type it points to.
+func typeString(t types.Type) string {
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = types.NewPointer(types.Unalias(ptr.Elem()))
 	}
+	return t.String()
+}
 
-	inspect.Preorder(nodeFilter, func(n ast.Node) {
-		typespec, ok := n.(*ast.TypeSpec)
-		if !ok {
-			return
-		}
-		typedef, ok := typespec.Type.(*ast.InterfaceType)
-		if !ok {
-			return
-		}
-		for _, m := range typedef.Methods.List {
-			funcT, ok := m.Type.(*ast.FuncType)
+// A Union is a closed tagged union together with the concrete types that are
+// its members.
+type Union struct {
+	Interface *types.Named
+	Members   []types.Type
+}
+
+// Find returns the closed tagged unions declared in files. The members of
+// each union are the concrete types declared in files that satisfy its
+// interface, ordered by name. A type whose methods have value receivers is a
+// member as T, and otherwise as *T.
+func Find(files []*ast.File, info *types.Info) []*Union {
+	unions := []*Union{}
+	// Find closed tagged unions. Consider using types.Type like guru instead
+	// (See https://github.com/golang/tools/blob/master/cmd/guru/implements.go)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			typespec, ok := n.(*ast.TypeSpec)
 			if !ok {
-				continue
+				return true
 			}
-			if funcT.Params.NumFields() != 0 || funcT.Results.NumFields() != 0 {
-				// This field takes parameters or returns
-				// values, this is not a union 'tag' field.
-				continue
+			typedef, ok := typespec.Type.(*ast.InterfaceType)
+			if !ok {
+				return true
 			}
-			for _, name := range m.Names {
-				if len(name.Name) == 0 {
-					// Not sure if this is possible, but if
-					// it is, this prevents an
-					// out-of-bounds error below.
+			for _, m := range typedef.Methods.List {
+				funcT, ok := m.Type.(*ast.FuncType)
+				if !ok {
+					continue
+				}
+				if funcT.Params.NumFields() != 0 || funcT.Results.NumFields() != 0 {
+					// This field takes parameters or returns
+					// values, this is not a union 'tag' field.
 					continue
 				}
-				if name.Name[0] >= 'a' && name.Name[0] <= 'z' {
-					// This type has a tag field! Record that fact.
-					t := pass.TypesInfo.TypeOf(typespec.Name).(*types.Named)
-					unions = append(unions, &union{Interface: t, Members: nil})
+				for _, name := range m.Names {
+					if len(name.Name) == 0 {
+						// Not sure if this is possible, but if
+						// it is, this prevents an
+						// out-of-bounds error below.
+						continue
+					}
+					if name.Name[0] >= 'a' && name.Name[0] <= 'z' {
+						// This type has a tag field! Record that fact.
+						t := info.TypeOf(typespec.Name).(*types.Named)
+						unions = append(unions, &Union{Interface: t, Members: nil})
+					}
 				}
 			}
-		}
-	})
-	for _, u := range unions {
-		// Find types that form part of the closed tagged union.
-		for _, typ := range pass.TypesInfo.Types {
-			if types.IsInterface(typ.Type) {
-				// Ignore interfaces as members of a closed tagged
-				// union must be concrete types.
+			return true
+		})
+	}
+	if len(unions) == 0 {
+		return unions
+	}
+	// Find the types that form part of the closed tagged unions. Only types
+	// declared in the same package can implement the unexported tag method,
+	// so their declarations hold every member, whether or not it is used.
+	var declared []*types.Named
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gendecl, ok := decl.(*ast.GenDecl)
+			if !ok || gendecl.Tok != token.TYPE {
 				continue
 			}
-			if types.AssignableTo(typ.Type, u.Interface) {
-				alreadyAssigned := false
-				for _, member := range u.Members {
-					if types.AssignableTo(member, typ.Type) && types.AssignableTo(typ.Type, member) &&
-						types.Identical(member, typ.Type) {
-						alreadyAssigned = true
-						break
-					}
+			for _, spec := range gendecl.Specs {
+				typespec := spec.(*ast.TypeSpec)
+				obj, ok := info.Defs[typespec.Name].(*types.TypeName)
+				if !ok || obj.IsAlias() {
+					continue
 				}
-				if !alreadyAssigned {
-					u.Members = append(u.Members, typ.Type)
+				named, ok := obj.Type().(*types.Named)
+				if !ok || named.TypeParams().Len() != 0 || types.IsInterface(named) {
+					// Members of a closed tagged union must be
+					// concrete, and the members of generic types
+					// aren't known.
+					continue
 				}
+				declared = append(declared, named)
+			}
+		}
+	}
+	for _, u := range unions {
+		iface := u.Interface.Underlying().(*types.Interface)
+		for _, named := range declared {
+			// A member with value receivers is T, so that both T
+			// and *T are values of the union.
+			if types.Implements(named, iface) {
+				u.Members = append(u.Members, named)
+			} else if ptr := types.NewPointer(named); types.Implements(ptr, iface) {
+				u.Members = append(u.Members, ptr)
 			}
 		}
-		pass.ExportObjectFact(u.Interface.Obj(), &union{u.Interface, u.Members})
+		// Sort the members by name so that callers see them in a
+		// stable order.
+		sort.Slice(u.Members, func(i, j int) bool {
+			return deref(u.Members[i]).String() < deref(u.Members[j]).String()
+		})
 	}
+	return unions
 }
 
 func checkTaggedUnions(pass *analysis.Pass) {
@@ -132,7 +200,7 @@ func checkTaggedUnions(pass *analysis.Pass) {
 	}
 	inspect.Preorder(nodeFilter, func(n ast.Node) {
 		stmt := n.(*ast.TypeSwitchStmt)
-		var typeAssertIdent *ast.Ident
+		var typeAssertExpr ast.Expr
 		switch x := stmt.Assign.(type) {
 		case *ast.ExprStmt:
 			texpr, ok := x.X.(*ast.TypeAssertExpr)
@@ -140,7 +208,7 @@ func checkTaggedUnions(pass *analysis.Pass) {
 				// We don't support this case.
 				return
 			}
-			typeAssertIdent = texpr.X.(*ast.Ident)
+			typeAssertExpr = texpr.X
 		case *ast.AssignStmt:
 			if len(x.Rhs) != 1 {
 				// We don't support this case.
@@ -151,12 +219,13 @@ func checkTaggedUnions(pass *analysis.Pass) {
 				// We don't support this case.
 				return
 			}
-			typeAssertIdent = texpr.X.(*ast.Ident)
+			typeAssertExpr = texpr.X
 		default:
 			// We don't support this case.
 			return
 		}
-		t := pass.TypesInfo.TypeOf(typeAssertIdent)
+		// Look through aliases of the union interface, e.g. 'type Bar = a.Foo'.
+		t := types.Unalias(pass.TypesInfo.TypeOf(typeAssertExpr))
 		named, ok := t.(*types.Named)
 		if !ok {
 			return
@@ -165,11 +234,12 @@ func checkTaggedUnions(pass *analysis.Pass) {
 		u := new(union)
 		ok = pass.ImportObjectFact(named.Obj(), u)
 		if !ok {
-			fmt.Printf("Cannot find object fact: %s\n", named.Obj())
+			// The interface isn't a union.
 			return
 		}
-		if !types.AssignableTo(t, u.Interface) || !types.AssignableTo(u.Interface, t) ||
-			!types.Identical(t, u.Interface) {
+		if named.Origin() != named {
+			// The members of instantiations of generic unions
+			// aren't known.
 			return
 		}
 		for _, stmt := range stmt.Body.List {
@@ -191,14 +261,19 @@ func checkTaggedUnions(pass *analysis.Pass) {
 				}
 				for _, caseEl := range caseClause.List {
 					caseT := pass.TypesInfo.TypeOf(caseEl)
-					if types.AssignableTo(member, caseT) && types.AssignableTo(caseT, member) &&
-						types.Identical(member, caseT) {
+					if caseT == nil {
+						continue
+					}
+					// A member with value receivers may be
+					// matched by its pointer, which implements
+					// the union too.
+					if s := typeString(caseT); s == member || s == "*"+member {
 						had = true
 					}
 				}
 			}
 			if !had {
-				pass.Reportf(stmt.Pos(), fmt.Sprintf("non-total type switch over union: missing %s", member.String()))
+				pass.Reportf(stmt.Pos(), "non-total type switch over union: missing %s", member)
 			}
 		}
 	})
@@ -9,5 +9,5 @@ import (
 
 func TestUnion(t *testing.T) {
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, union.Analyzer, "a", "b") // loads testdata/src/
+	analysistest.Run(t, testdata, union.Analyzer, "a", "b", "c") // loads testdata/src/
 }
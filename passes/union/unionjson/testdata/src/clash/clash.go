@@ -0,0 +1,22 @@
+package clash
+
+type Event interface {
+	isEvent()
+}
+
+type Click struct {
+	X, Y int
+}
+
+func (*Click) isEvent() {}
+
+type Key struct {
+	Meta
+	Code int
+}
+
+func (*Key) isEvent() {}
+
+type Meta struct {
+	Kind string `json:"type,omitempty"`
+}
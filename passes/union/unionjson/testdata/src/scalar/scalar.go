@@ -0,0 +1,16 @@
+package scalar
+
+type Unit interface {
+	isUnit()
+}
+
+type Meters struct {
+	Value float64
+}
+
+func (Meters) isUnit() {}
+
+// Feet can't hold the discriminator field of its encoded value.
+type Feet float64
+
+func (Feet) isUnit() {}
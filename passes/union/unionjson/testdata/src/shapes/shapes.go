@@ -0,0 +1,32 @@
+package shapes
+
+import "math"
+
+type Shape interface {
+	Area() float64
+	isShape()
+}
+
+type Circle struct {
+	Radius float64
+}
+
+func (c *Circle) Area() float64 { return math.Pi * c.Radius * c.Radius }
+func (*Circle) isShape()        {}
+
+//cederstone:discriminator square
+type Square struct {
+	Side float64
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+func (Square) isShape()        {}
+
+type Triangle struct {
+	_      struct{} `cederstone:"discriminator=tri"`
+	Base   float64
+	Height float64
+}
+
+func (t *Triangle) Area() float64 { return t.Base * t.Height / 2 }
+func (*Triangle) isShape()        {}
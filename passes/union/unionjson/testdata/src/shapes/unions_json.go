@@ -0,0 +1,91 @@
+// Code generated by unionjson; DO NOT EDIT.
+
+package shapes
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ShapeJSON wraps a Shape so that it can be marshalled to and from JSON.
+// The member type is recorded in the "type" field of the encoded object.
+type ShapeJSON struct {
+	Value Shape
+}
+
+//cederstone:unionjson Shape
+var shapeDiscriminators = map[string]func(data []byte) (Shape, error){
+	"Circle": func(data []byte) (Shape, error) {
+		v := new(Circle)
+		err := json.Unmarshal(data, v)
+		return v, err
+	},
+	"square": func(data []byte) (Shape, error) {
+		var v Square
+		err := json.Unmarshal(data, &v)
+		return v, err
+	},
+	"tri": func(data []byte) (Shape, error) {
+		v := new(Triangle)
+		err := json.Unmarshal(data, v)
+		return v, err
+	},
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u ShapeJSON) MarshalJSON() ([]byte, error) {
+	var discriminator string
+	switch u.Value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case *Circle:
+		discriminator = "Circle"
+	case *Square, Square:
+		discriminator = "square"
+	case *Triangle:
+		discriminator = "tri"
+	default:
+		return nil, fmt.Errorf("unknown Shape member %T", u.Value)
+	}
+	data, err := json.Marshal(u.Value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		// The member is a nil pointer.
+		fields = map[string]json.RawMessage{}
+	}
+	fields["type"], err = json.Marshal(discriminator)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *ShapeJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		u.Value = nil
+		return nil
+	}
+	var header struct {
+		Discriminator string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	decode, ok := shapeDiscriminators[header.Discriminator]
+	if !ok {
+		return fmt.Errorf("unknown Shape discriminator %q", header.Discriminator)
+	}
+	v, err := decode(data)
+	if err != nil {
+		return err
+	}
+	u.Value = v
+	return nil
+}
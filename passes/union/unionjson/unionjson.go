@@ -0,0 +1,362 @@
+// Package unionjson generates JSON marshalling helpers for closed tagged
+// unions.
+//
+// For each union found by the union pass, Generate produces a wrapper struct
+// holding a value of the union with MarshalJSON and UnmarshalJSON methods.
+// The encoded object of a member carries an extra discriminator field naming
+// the member so that it can be decoded into the right type, so members must
+// be structs or pointers to structs, without a JSON field of their own named
+// like the discriminator field.
+//
+// A member's discriminator is its type name unless overridden by a directive
+// in the doc comment of its type declaration:
+//
+//	//cederstone:discriminator circle
+//	type Circle struct{ ... }
+//
+// or by a struct tag on any of its fields:
+//
+//	type Circle struct {
+//		_ struct{} `cederstone:"discriminator=circle"`
+//	}
+//
+// The generated registry of discriminators is marked so that the union pass
+// can warn about members that were added after the helpers were generated.
+package unionjson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/cederstone/analysis/passes/union"
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultField is the name of the JSON field that holds the discriminator
+// unless configured otherwise.
+const DefaultField = "type"
+
+const discriminatorDirective = "//cederstone:discriminator "
+
+// Options configure the generated code.
+type Options struct {
+	// Field is the name of the JSON field holding the discriminator.
+	Field string
+	// Types restricts generation to the unions with these names. All
+	// unions in the package are generated if it is empty.
+	Types []string
+}
+
+// Generate returns the source of a file implementing JSON marshalling for the
+// unions declared in pkg.
+func Generate(pkg *packages.Package, opts Options) ([]byte, error) {
+	if opts.Field == "" {
+		opts.Field = DefaultField
+	}
+	data := fileData{Package: pkg.Name, Field: opts.Field}
+	for _, u := range union.Find(pkg.Syntax, pkg.TypesInfo) {
+		name := u.Interface.Obj().Name()
+		if len(opts.Types) != 0 && !contains(opts.Types, name) {
+			continue
+		}
+		ud := unionData{
+			Name:     name,
+			Wrapper:  name + "JSON",
+			Registry: strings.ToLower(name[:1]) + name[1:] + "Discriminators",
+		}
+		seen := map[string]string{}
+		for _, member := range u.Members {
+			md, err := memberData(pkg, member, opts.Field)
+			if err != nil {
+				return nil, fmt.Errorf("union %s: %v", name, err)
+			}
+			if other, ok := seen[md.Discriminator]; ok {
+				return nil, fmt.Errorf("union %s: members %s and %s share discriminator %q", name, other, md.Elem, md.Discriminator)
+			}
+			seen[md.Discriminator] = md.Elem
+			ud.Members = append(ud.Members, md)
+		}
+		data.Unions = append(data.Unions, ud)
+	}
+	if len(data.Unions) == 0 {
+		return nil, fmt.Errorf("no unions found in %s", pkg.PkgPath)
+	}
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+type fileData struct {
+	Package string
+	Field   string
+	Unions  []unionData
+}
+
+type unionData struct {
+	Name     string
+	Wrapper  string
+	Registry string
+	Members  []member
+}
+
+type member struct {
+	// Cases are the member types as written in the generated package.
+	Cases []string
+	// Elem is the type that is allocated when decoding the member.
+	Elem          string
+	Pointer       bool
+	Discriminator string
+}
+
+// memberData returns the data of the member t of a union of pkg, whose
+// discriminator is encoded in field.
+func memberData(pkg *packages.Package, t types.Type, field string) (member, error) {
+	m := member{}
+	elem := t
+	if ptr, ok := t.(*types.Pointer); ok {
+		m.Pointer = true
+		elem = ptr.Elem()
+	}
+	named, ok := elem.(*types.Named)
+	if !ok || named.Obj().Pkg() != pkg.Types {
+		return m, fmt.Errorf("member %s is not a named type declared in %s", t, pkg.PkgPath)
+	}
+	qualifier := types.RelativeTo(pkg.Types)
+	m.Elem = types.TypeString(elem, qualifier)
+	s, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		// The discriminator is added as a field of the encoded object.
+		return m, fmt.Errorf("member %s is not a struct type", types.TypeString(t, qualifier))
+	}
+	for _, name := range jsonFields(s, nil) {
+		// The discriminator would overwrite the field when
+		// encoding, and the field be set to the discriminator
+		// when decoding, which matches names case-insensitively.
+		if strings.EqualFold(name, field) {
+			return m, fmt.Errorf("member %s has JSON field %q, which clashes with discriminator field %q", m.Elem, name, field)
+		}
+	}
+	if m.Pointer {
+		m.Cases = []string{"*" + m.Elem}
+	} else {
+		// Values of *T are members too, decode into T and encode
+		// either of them.
+		m.Cases = []string{"*" + m.Elem, m.Elem}
+	}
+	m.Discriminator = named.Obj().Name()
+	if d, ok := directiveDiscriminator(pkg.Syntax, named.Obj()); ok {
+		m.Discriminator = d
+	}
+	if d, ok := tagDiscriminator(named); ok {
+		m.Discriminator = d
+	}
+	return m, nil
+}
+
+// directiveDiscriminator returns the discriminator set by a directive in the
+// doc comment of obj's declaration.
+func directiveDiscriminator(files []*ast.File, obj types.Object) (string, bool) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gendecl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gendecl.Specs {
+				typespec, ok := spec.(*ast.TypeSpec)
+				if !ok || typespec.Name.Pos() != obj.Pos() {
+					continue
+				}
+				doc := typespec.Doc
+				if doc == nil && len(gendecl.Specs) == 1 {
+					doc = gendecl.Doc
+				}
+				if doc == nil {
+					return "", false
+				}
+				for _, c := range doc.List {
+					if strings.HasPrefix(c.Text, discriminatorDirective) {
+						return strings.TrimSpace(strings.TrimPrefix(c.Text, discriminatorDirective)), true
+					}
+				}
+				return "", false
+			}
+		}
+	}
+	return "", false
+}
+
+// tagDiscriminator returns the discriminator set by a struct tag on one of
+// named's fields.
+func tagDiscriminator(named *types.Named) (string, bool) {
+	s, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return "", false
+	}
+	for ii := 0; ii < s.NumFields(); ii++ {
+		value, ok := reflect.StructTag(s.Tag(ii)).Lookup("cederstone")
+		if !ok {
+			continue
+		}
+		for _, opt := range strings.Split(value, ",") {
+			if strings.HasPrefix(opt, "discriminator=") {
+				return strings.TrimPrefix(opt, "discriminator="), true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonFields returns the names of the JSON fields that encoding/json encodes
+// a value of s with, including those promoted from embedded structs. seen
+// holds the embedded structs already visited.
+func jsonFields(s *types.Struct, seen map[*types.Struct]bool) []string {
+	if seen[s] {
+		return nil
+	}
+	if seen == nil {
+		seen = map[*types.Struct]bool{}
+	}
+	seen[s] = true
+	var names []string
+	for ii := 0; ii < s.NumFields(); ii++ {
+		f := s.Field(ii)
+		tag := reflect.StructTag(s.Tag(ii)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Embedded() && name == "" {
+			t := f.Type()
+			if ptr, ok := t.Underlying().(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				names = append(names, jsonFields(embedded, seen)...)
+				continue
+			}
+		}
+		if !f.Exported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name()
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+var fileTemplate = template.Must(template.New("unionjson").Funcs(template.FuncMap{
+	"join":  strings.Join,
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by unionjson; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+	"fmt"
+)
+{{$field := .Field}}
+{{- range .Unions}}{{$union := .}}
+// {{.Wrapper}} wraps a {{.Name}} so that it can be marshalled to and from JSON.
+// The member type is recorded in the {{quote $field}} field of the encoded object.
+type {{.Wrapper}} struct {
+	Value {{.Name}}
+}
+
+{{"//"}}cederstone:unionjson {{.Name}}
+var {{.Registry}} = map[string]func(data []byte) ({{.Name}}, error){
+{{- range .Members}}
+	{{quote .Discriminator}}: func(data []byte) ({{$union.Name}}, error) {
+		{{- if .Pointer}}
+		v := new({{.Elem}})
+		err := json.Unmarshal(data, v)
+		{{- else}}
+		var v {{.Elem}}
+		err := json.Unmarshal(data, &v)
+		{{- end}}
+		return v, err
+	},
+{{- end}}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u {{.Wrapper}}) MarshalJSON() ([]byte, error) {
+	var discriminator string
+	switch u.Value.(type) {
+	case nil:
+		return []byte("null"), nil
+{{- range .Members}}
+	case {{join .Cases ", "}}:
+		discriminator = {{quote .Discriminator}}
+{{- end}}
+	default:
+		return nil, fmt.Errorf("unknown {{.Name}} member %T", u.Value)
+	}
+	data, err := json.Marshal(u.Value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		// The member is a nil pointer.
+		fields = map[string]json.RawMessage{}
+	}
+	fields[{{quote $field}}], err = json.Marshal(discriminator)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *{{.Wrapper}}) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		u.Value = nil
+		return nil
+	}
+	var header struct {
+		Discriminator string ` + "`json:{{quote $field}}`" + `
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	decode, ok := {{.Registry}}[header.Discriminator]
+	if !ok {
+		return fmt.Errorf("unknown {{.Name}} discriminator %q", header.Discriminator)
+	}
+	v, err := decode(data)
+	if err != nil {
+		return err
+	}
+	u.Value = v
+	return nil
+}
+{{end}}`))
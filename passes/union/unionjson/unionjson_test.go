@@ -0,0 +1,108 @@
+package unionjson_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cederstone/analysis/passes/union/unionjson"
+	"github.com/cederstone/analysis/passes/union/unionjson/testdata/src/shapes"
+	"golang.org/x/tools/go/packages"
+)
+
+func load(t *testing.T, dir string) *packages.Package {
+	t.Helper()
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		t.Fatal("failed to load testdata")
+	}
+	return pkgs[0]
+}
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("testdata", "src", "shapes")
+	got, err := unionjson.Generate(load(t, dir), unionjson.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The checked in output must be reproduced when regenerating it.
+	want, err := ioutil.ReadFile(filepath.Join(dir, "unions_json.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code differs from unions_json.go:\n%s", got)
+	}
+}
+
+func TestGenerateNonStruct(t *testing.T) {
+	pkg := load(t, filepath.Join("testdata", "src", "scalar"))
+	_, err := unionjson.Generate(pkg, unionjson.Options{})
+	want := "union Unit: member Feet is not a struct type"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Generate() error = %v, want %q", err, want)
+	}
+}
+
+func TestGenerateFieldClash(t *testing.T) {
+	pkg := load(t, filepath.Join("testdata", "src", "clash"))
+	// The field promoted from Meta is encoded as the default
+	// discriminator field.
+	_, err := unionjson.Generate(pkg, unionjson.Options{})
+	want := `union Event: member Key has JSON field "type", which clashes with discriminator field "type"`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Generate() error = %v, want %q", err, want)
+	}
+	if _, err := unionjson.Generate(pkg, unionjson.Options{Field: "kind"}); err != nil {
+		t.Errorf("Generate() with field kind: %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, shape := range []shapes.Shape{
+		&shapes.Circle{Radius: 1},
+		shapes.Square{Side: 2},
+		&shapes.Triangle{Base: 3, Height: 4},
+		nil,
+	} {
+		data, err := json.Marshal(shapes.ShapeJSON{Value: shape})
+		if err != nil {
+			t.Fatalf("marshalling %#v: %v", shape, err)
+		}
+		var got shapes.ShapeJSON
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshalling %s: %v", data, err)
+		}
+		if !reflect.DeepEqual(got.Value, shape) {
+			t.Errorf("round trip of %#v through %s = %#v", shape, data, got.Value)
+		}
+	}
+
+	// Pointers to members with value receivers decode into values.
+	data, err := json.Marshal(shapes.ShapeJSON{Value: &shapes.Square{Side: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Side":2,"type":"square"}`; string(data) != want {
+		t.Errorf("marshalled *Square = %s, want %s", data, want)
+	}
+	var got shapes.ShapeJSON
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (shapes.Square{Side: 2}); got.Value != want {
+		t.Errorf("unmarshalled *Square = %#v, want %#v", got.Value, want)
+	}
+}
@@ -0,0 +1,149 @@
+// Package plugin registers the analysis passes of this module as a
+// golangci-lint module plugin named 'cederstone'.
+//
+// The plugin is built into golangci-lint by 'golangci-lint custom' with a
+// .custom-gcl.yml file importing this package:
+//
+//	version: v2.1.0
+//	plugins:
+//	  - module: github.com/cederstone/analysis
+//	    import: github.com/cederstone/analysis/plugin
+//	    version: latest
+//
+// and enabled in the golangci-lint config, whose settings enable and disable
+// passes and set their flags as in .cedercheck.yaml files:
+//
+//	linters:
+//	  enable:
+//	    - cederstone
+//	  settings:
+//	    custom:
+//	      cederstone:
+//	        type: module
+//	        settings:
+//	          nakedreturn:
+//	            enabled: false
+//	          keyedlit:
+//	            flags:
+//	              strict: true
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cederstone/analysis/passes/enum"
+	"github.com/cederstone/analysis/passes/funcopts"
+	"github.com/cederstone/analysis/passes/keyedlit"
+	"github.com/cederstone/analysis/passes/nakedreturn"
+	"github.com/cederstone/analysis/passes/union"
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Name is the name of the plugin, and of its linter in golangci-lint.
+const Name = "cederstone"
+
+func init() {
+	register.Plugin(Name, New)
+}
+
+// Settings are the settings of the plugin, by analyzer name.
+type Settings map[string]*AnalyzerSettings
+
+// AnalyzerSettings enable or disable an analyzer and set its flags.
+// Analyzers are enabled unless disabled.
+type AnalyzerSettings struct {
+	Enabled *bool                  `json:"enabled"`
+	Flags   map[string]interface{} `json:"flags"`
+}
+
+type plugin struct {
+	settings Settings
+}
+
+// New returns the plugin configured by the settings of golangci-lint.
+func New(settings interface{}) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin{settings: s}, nil
+}
+
+// BuildAnalyzers returns the enabled analyzers, with their flags set.
+func (p *plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	all := []*analysis.Analyzer{
+		enum.Analyzer,
+		funcopts.Analyzer,
+		keyedlit.Analyzer,
+		nakedreturn.Analyzer,
+		union.Analyzer,
+	}
+	byName := map[string]*analysis.Analyzer{}
+	for _, a := range all {
+		byName[a.Name] = a
+	}
+	names := make([]string, 0, len(p.settings))
+	for name := range p.settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown analyzer %s", name)
+		}
+		s := p.settings[name]
+		if s == nil {
+			continue
+		}
+		flags := make([]string, 0, len(s.Flags))
+		for flag := range s.Flags {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		for _, flag := range flags {
+			if a.Flags.Lookup(flag) == nil {
+				return nil, fmt.Errorf("%s: unknown flag %s", name, flag)
+			}
+			if err := a.Flags.Set(flag, flagValue(s.Flags[flag])); err != nil {
+				return nil, fmt.Errorf("%s: flag %s: %v", name, flag, err)
+			}
+		}
+	}
+
+	var enabled []*analysis.Analyzer
+	for _, a := range all {
+		if s := p.settings[a.Name]; s == nil || s.Enabled == nil || *s.Enabled {
+			enabled = append(enabled, a)
+		}
+	}
+	return enabled, nil
+}
+
+// GetLoadMode returns the load mode of the analyzers, which need type
+// information.
+func (p *plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
+
+// flagValue returns the value of a flag decoded from JSON as a string. Lists
+// are passed as comma-separated values.
+func flagValue(value interface{}) string {
+	switch value := value.(type) {
+	case []interface{}:
+		var elems []string
+		for _, v := range value {
+			elems = append(elems, flagValue(v))
+		}
+		return strings.Join(elems, ",")
+	case float64:
+		// Avoid the exponent format of large numbers.
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
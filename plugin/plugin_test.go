@@ -0,0 +1,70 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/cederstone/analysis/passes/nakedreturn"
+	"github.com/cederstone/analysis/plugin"
+	"github.com/golangci/plugin-module-register/register"
+)
+
+func TestPlugin(t *testing.T) {
+	newPlugin, err := register.GetPlugin(plugin.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Settings are decoded from the JSON encoding of the golangci-lint
+	// config.
+	p, err := newPlugin(map[string]interface{}{
+		"enum": map[string]interface{}{"enabled": false},
+		"nakedreturn": map[string]interface{}{
+			"flags": map[string]interface{}{"max-lines": 1000000.0},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxLines := nakedreturn.Analyzer.Flags.Lookup("max-lines")
+	defer maxLines.Value.Set(maxLines.Value.String())
+
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range analyzers {
+		names = append(names, a.Name)
+	}
+	want := []string{"funcopts", "keyedlit", "nakedreturn", "union"}
+	if len(names) != len(want) {
+		t.Fatalf("BuildAnalyzers() = %v, want %v", names, want)
+	}
+	for ii := range want {
+		if names[ii] != want[ii] {
+			t.Fatalf("BuildAnalyzers() = %v, want %v", names, want)
+		}
+	}
+	if got := maxLines.Value.String(); got != "1000000" {
+		t.Errorf("max-lines flag = %s, want 1000000", got)
+	}
+	if got := p.GetLoadMode(); got != register.LoadModeTypesInfo {
+		t.Errorf("GetLoadMode() = %s, want %s", got, register.LoadModeTypesInfo)
+	}
+}
+
+func TestPluginErrors(t *testing.T) {
+	for _, settings := range []map[string]interface{}{
+		{"unknown": map[string]interface{}{}},
+		{"keyedlit": map[string]interface{}{"flags": map[string]interface{}{"unknown": true}}},
+		{"keyedlit": map[string]interface{}{"unknown": true}},
+		{"nakedreturn": map[string]interface{}{"flags": map[string]interface{}{"max-lines": "many"}}},
+	} {
+		p, err := plugin.New(settings)
+		if err != nil {
+			continue
+		}
+		if _, err := p.BuildAnalyzers(); err == nil {
+			t.Errorf("BuildAnalyzers() with settings %v succeeded, want an error", settings)
+		}
+	}
+}